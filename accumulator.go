@@ -0,0 +1,92 @@
+package gpt3
+
+import "sort"
+
+// ChoiceDelta is what a chunk of a streamed completion added to one of its choices
+type ChoiceDelta struct {
+	// Index is the index of the choice
+	Index int
+	// PromptIndex is the index of the prompt of the choice, see CompletionResponseChoice.PromptIndex
+	PromptIndex int
+	// Text is the text added to the choice
+	Text string
+	// FinishReason is set by the last chunk of the choice
+	FinishReason string
+	// Choice is the choice accumulated so far, including this delta
+	Choice CompletionResponseChoice
+}
+
+// StreamAccumulator reassembles the chunks of a streamed completion. The chunks of the different choices
+// of a stream with N > 1 or several prompts arrive interleaved, and are merged by choice index. The zero
+// value is ready to use, but it isn't safe for concurrent use.
+type StreamAccumulator struct {
+	response CompletionResponse
+}
+
+// Add merges the chunk into the completion and returns what it added to each of its choices, in the
+// order of the chunk.
+func (a *StreamAccumulator) Add(chunk *CompletionResponse) []ChoiceDelta {
+	if a.response.ID == "" {
+		a.response.ID = chunk.ID
+		a.response.Object = chunk.Object
+		a.response.Created = chunk.Created
+		a.response.Model = chunk.Model
+	}
+	if chunk.Usage.TotalTokens > 0 {
+		a.response.Usage = chunk.Usage
+	}
+
+	deltas := make([]ChoiceDelta, 0, len(chunk.Choices))
+	for _, delta := range chunk.Choices {
+		choice := a.choice(delta.Index, delta.PromptIndex)
+		choice.Text += delta.Text
+		choice.LogProbs.Tokens = append(choice.LogProbs.Tokens, delta.LogProbs.Tokens...)
+		choice.LogProbs.TokenLogprobs = append(choice.LogProbs.TokenLogprobs, delta.LogProbs.TokenLogprobs...)
+		choice.LogProbs.TopLogprobs = append(choice.LogProbs.TopLogprobs, delta.LogProbs.TopLogprobs...)
+		choice.LogProbs.TextOffset = append(choice.LogProbs.TextOffset, delta.LogProbs.TextOffset...)
+		if delta.FinishReason != "" {
+			choice.FinishReason = delta.FinishReason
+		}
+		deltas = append(deltas, ChoiceDelta{
+			Index:        delta.Index,
+			PromptIndex:  delta.PromptIndex,
+			Text:         delta.Text,
+			FinishReason: delta.FinishReason,
+			Choice:       *choice,
+		})
+	}
+	return deltas
+}
+
+// choice returns the accumulated choice with the index, adding it in order of index when it's new
+func (a *StreamAccumulator) choice(index, promptIndex int) *CompletionResponseChoice {
+	choices := a.response.Choices
+	i := sort.Search(len(choices), func(i int) bool {
+		return choices[i].Index >= index
+	})
+	if i == len(choices) || choices[i].Index != index {
+		choices = append(choices, CompletionResponseChoice{})
+		copy(choices[i+1:], choices[i:])
+		choices[i] = CompletionResponseChoice{Index: index, PromptIndex: promptIndex}
+		a.response.Choices = choices
+	}
+	return &a.response.Choices[i]
+}
+
+// Choice returns the choice with the index accumulated so far, and whether any chunk of it was added
+func (a *StreamAccumulator) Choice(index int) (CompletionResponseChoice, bool) {
+	for _, choice := range a.response.Choices {
+		if choice.Index == index {
+			return choice, true
+		}
+	}
+	return CompletionResponseChoice{}, false
+}
+
+// Response returns the completion accumulated from the chunks added so far, with its choices sorted by
+// index. Once every chunk of a stream was added, it's the response Completion returns for the request.
+func (a *StreamAccumulator) Response() *CompletionResponse {
+	response := a.response
+	response.Choices = append([]CompletionResponseChoice(nil), a.response.Choices...)
+	return &response
+}
@@ -0,0 +1,97 @@
+package gpt3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestStreamAccumulator(t *testing.T) {
+	logprobs := func(tokens ...string) LogprobResult {
+		result := LogprobResult{Tokens: tokens}
+		for _, token := range tokens {
+			result.TokenLogprobs = append(result.TokenLogprobs, -0.5)
+			result.TopLogprobs = append(result.TopLogprobs, map[string]float32{token: -0.5})
+			result.TextOffset = append(result.TextOffset, len(token))
+		}
+		return result
+	}
+	chunk := func(choices ...CompletionResponseChoice) *CompletionResponse {
+		return &CompletionResponse{ID: "cmpl-1", Object: "text_completion", Created: 1680000000, Model: "text-davinci-003", Choices: choices}
+	}
+
+	var accumulator StreamAccumulator
+	_, ok := accumulator.Choice(0)
+	assert.False(t, ok)
+
+	deltas := accumulator.Add(chunk(
+		CompletionResponseChoice{Text: " Tok", Index: 2, PromptIndex: 1, LogProbs: logprobs(" Tok")},
+		CompletionResponseChoice{Text: " Par", Index: 0, LogProbs: logprobs(" Par")},
+	))
+	assert.Equal(t, []ChoiceDelta{
+		{Index: 2, PromptIndex: 1, Text: " Tok", Choice: CompletionResponseChoice{Text: " Tok", Index: 2, PromptIndex: 1, LogProbs: logprobs(" Tok")}},
+		{Index: 0, Text: " Par", Choice: CompletionResponseChoice{Text: " Par", Index: 0, LogProbs: logprobs(" Par")}},
+	}, deltas)
+
+	accumulator.Add(chunk(CompletionResponseChoice{Text: " Paris", Index: 1, LogProbs: logprobs(" Paris"), FinishReason: "stop"}))
+	deltas = accumulator.Add(chunk(CompletionResponseChoice{Text: "is", Index: 0, LogProbs: logprobs("is"), FinishReason: "length"}))
+	assert.Equal(t, "is", deltas[0].Text)
+	assert.Equal(t, "length", deltas[0].FinishReason)
+	assert.Equal(t, " Paris", deltas[0].Choice.Text)
+	accumulator.Add(chunk(
+		CompletionResponseChoice{Text: "yo", Index: 2, PromptIndex: 1, LogProbs: logprobs("yo"), FinishReason: "stop"},
+		CompletionResponseChoice{Text: " Tokyo", Index: 3, PromptIndex: 1, LogProbs: logprobs(" Tokyo"), FinishReason: "stop"},
+	))
+
+	choice, ok := accumulator.Choice(2)
+	assert.True(t, ok)
+	assert.Equal(t, " Tokyo", choice.Text)
+
+	assert.Equal(t, &CompletionResponse{
+		ID:      "cmpl-1",
+		Object:  "text_completion",
+		Created: 1680000000,
+		Model:   "text-davinci-003",
+		Choices: []CompletionResponseChoice{
+			{Text: " Paris", Index: 0, LogProbs: logprobs(" Par", "is"), FinishReason: "length"},
+			{Text: " Paris", Index: 1, LogProbs: logprobs(" Paris"), FinishReason: "stop"},
+			{Text: " Tokyo", Index: 2, PromptIndex: 1, LogProbs: logprobs(" Tok", "yo"), FinishReason: "stop"},
+			{Text: " Tokyo", Index: 3, PromptIndex: 1, LogProbs: logprobs(" Tokyo"), FinishReason: "stop"},
+		},
+	}, accumulator.Response())
+}
+
+func TestStreamAccumulatorMatchesCompletion(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+	request := CompletionRequest{Prompt: PromptStrings("France", "Japan"), N: IntPtr(2)}
+
+	rt.RoundTripReturnsOnCall(0, statusResponse(200, nil, `{"id":"cmpl-1","object":"text_completion","model":"ada","choices":[`+
+		`{"text":" Paris","index":0,"logprobs":null,"finish_reason":"stop"},`+
+		`{"text":" Lyon","index":1,"logprobs":null,"finish_reason":"stop"},`+
+		`{"text":" Tokyo","index":2,"logprobs":null,"finish_reason":"stop"},`+
+		`{"text":" Kyoto","index":3,"logprobs":null,"finish_reason":"length"}]}`), nil)
+	rt.RoundTripReturnsOnCall(1, statusResponse(200, nil, ""+
+		`data: {"id":"cmpl-1","object":"text_completion","model":"ada","choices":[{"text":" Ky","index":3,"logprobs":null,"finish_reason":null}]}`+"\n\n"+
+		`data: {"id":"cmpl-1","object":"text_completion","model":"ada","choices":[{"text":" Paris","index":0,"logprobs":null,"finish_reason":"stop"}]}`+"\n\n"+
+		`data: {"id":"cmpl-1","object":"text_completion","model":"ada","choices":[{"text":" Tokyo","index":2,"logprobs":null,"finish_reason":"stop"}]}`+"\n\n"+
+		`data: {"id":"cmpl-1","object":"text_completion","model":"ada","choices":[{"text":" Lyon","index":1,"logprobs":null,"finish_reason":"stop"}]}`+"\n\n"+
+		`data: {"id":"cmpl-1","object":"text_completion","model":"ada","choices":[{"text":"oto","index":3,"logprobs":null,"finish_reason":"length"}]}`+"\n\n"+
+		"data: [DONE]\n\n"), nil)
+
+	completion, err := client.Completion(ctx, request)
+	assert.NoError(t, err)
+
+	var accumulator StreamAccumulator
+	var texts []string
+	err = client.CompletionStream(ctx, request, func(chunk *CompletionResponse) {
+		for _, delta := range accumulator.Add(chunk) {
+			texts = append(texts, delta.Choice.Text)
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{" Ky", " Paris", " Tokyo", " Lyon", " Kyoto"}, texts)
+	assert.Equal(t, completion, accumulator.Response())
+}
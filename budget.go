@@ -0,0 +1,331 @@
+package gpt3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Budget periods
+const (
+	BudgetPeriodDaily   = "daily"
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodTotal   = "total"
+)
+
+// Budget configures the spending caps enabled by WithBudget. The spend is estimated with the usage
+// reported by the responses, or with the estimated tokens of the request when a streamed response
+// doesn't report its usage.
+type Budget struct {
+	// Daily, Monthly and Total cap the dollars spent in a day, in a calendar month and overall. Zero
+	// means no cap.
+	Daily   float64
+	Monthly float64
+	Total   float64
+	// Prices are used to estimate the spend. Defaults to DefaultPriceTable.
+	Prices PriceTable
+	// SoftThresholds are fractions of the caps, like 0.5 and 0.9, at which OnThreshold is called once
+	// per period
+	SoftThresholds []float64
+	// OnThreshold is called with the spend that crossed a soft threshold
+	OnThreshold func(BudgetAlert)
+	// StateFile persists the spend so that it survives restarts. The file is created when missing.
+	StateFile string
+	// OnSaveError is called when the state file couldn't be written. The spend is still tracked in
+	// memory, and the file is written again after the next request.
+	OnSaveError func(error)
+	// Location is the time zone of the days and months. Defaults to UTC.
+	Location *time.Location
+}
+
+// BudgetAlert reports the spend that crossed a soft threshold of a Budget
+type BudgetAlert struct {
+	// Key is the budget key of the requests, see WithBudgetKey
+	Key string
+	// Period is one of BudgetPeriodDaily, BudgetPeriodMonthly and BudgetPeriodTotal
+	Period    string
+	Threshold float64
+	Spent     float64
+	Cap       float64
+}
+
+type budgetKey struct{}
+
+// WithBudgetKey returns a context whose requests are accounted against a separate budget identified by
+// key, with the same caps as the other keys. Requests without a key share the budget of the empty key.
+func WithBudgetKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, budgetKey{}, key)
+}
+
+// budgetState is the spend of a budget key, as persisted in the state file
+type budgetState struct {
+	Day     string  `json:"day"`
+	Daily   float64 `json:"daily"`
+	Month   string  `json:"month"`
+	Monthly float64 `json:"monthly"`
+	Total   float64 `json:"total"`
+	// the estimated spend of the requests in flight, which is not persisted
+	pending float64
+}
+
+// roll resets the spend of the periods that ended
+func (s *budgetState) roll(now time.Time) {
+	if day := now.Format("2006-01-02"); s.Day != day {
+		s.Day, s.Daily = day, 0
+	}
+	if month := now.Format("2006-01"); s.Month != month {
+		s.Month, s.Monthly = month, 0
+	}
+}
+
+// budget tracks the spend of the requests of a client against a Budget
+type budget struct {
+	mu     sync.Mutex
+	limits Budget
+	now    func() time.Time
+	states map[string]*budgetState
+	// err fails every request when the state file couldn't be read
+	err error
+	// version counts the changes of the states, and saved is the version in the state file
+	version int
+	saved   int
+	// saveMu serializes the writes of the state file, which happen without holding mu
+	saveMu sync.Mutex
+}
+
+func newBudget(limits Budget) *budget {
+	if limits.Prices == nil {
+		limits.Prices = DefaultPriceTable
+	}
+	if limits.Location == nil {
+		limits.Location = time.UTC
+	}
+	b := &budget{
+		limits: limits,
+		now:    time.Now,
+		states: map[string]*budgetState{},
+	}
+	if limits.StateFile != "" {
+		b.err = b.load()
+	}
+	return b
+}
+
+func (b *budget) load() error {
+	data, err := ioutil.ReadFile(b.limits.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed reading budget state: %w", err)
+	}
+	if err := json.Unmarshal(data, &b.states); err != nil {
+		return fmt.Errorf("invalid budget state: %w", err)
+	}
+	return nil
+}
+
+// save writes the latest states to the state file unless another call already did. Concurrent calls
+// wait for each other, and write the file once for all the changes they made.
+func (b *budget) save() error {
+	b.saveMu.Lock()
+	defer b.saveMu.Unlock()
+
+	b.mu.Lock()
+	version := b.version
+	data, err := json.Marshal(b.states)
+	b.mu.Unlock()
+	if err != nil || version == b.saved {
+		return err
+	}
+	if err := b.write(data); err != nil {
+		return err
+	}
+	b.saved = version
+	return nil
+}
+
+// write writes the data to a temporary file that replaces the state file, so that a crash never leaves
+// a partially written state behind
+func (b *budget) write(data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(b.limits.StateFile), filepath.Base(b.limits.StateFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed writing budget state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed writing budget state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed writing budget state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.limits.StateFile); err != nil {
+		return fmt.Errorf("failed writing budget state: %w", err)
+	}
+	return nil
+}
+
+func (b *budget) state(key string) *budgetState {
+	state, ok := b.states[key]
+	if !ok {
+		state = &budgetState{}
+		b.states[key] = state
+	}
+	state.roll(b.now().In(b.limits.Location))
+	return state
+}
+
+// reserve checks that the budget of the key isn't exhausted and holds the estimated cost of a request
+// until it completes
+func (b *budget) reserve(key string, estimate float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	state := b.state(key)
+	caps := []struct {
+		period string
+		spent  float64
+		cap    float64
+	}{
+		{BudgetPeriodDaily, state.Daily, b.limits.Daily},
+		{BudgetPeriodMonthly, state.Monthly, b.limits.Monthly},
+		{BudgetPeriodTotal, state.Total, b.limits.Total},
+	}
+	for _, c := range caps {
+		if c.cap > 0 && c.spent+state.pending >= c.cap {
+			return fmt.Errorf("%w: %s spend of $%.2f reached the cap of $%.2f", ErrBudgetExceeded, c.period, c.spent+state.pending, c.cap)
+		}
+	}
+	state.pending += estimate
+	return nil
+}
+
+// settle replaces the estimated cost of a completed request by its actual cost
+func (b *budget) settle(key string, estimate, cost float64) {
+	alerts := b.account(key, estimate, cost)
+
+	// the callbacks and the file are called and written without holding the lock, so callbacks may use
+	// the client and the other requests don't wait for the disk. A failed write is retried by the
+	// next settled request.
+	if cost > 0 && b.limits.StateFile != "" {
+		if err := b.save(); err != nil && b.limits.OnSaveError != nil {
+			b.limits.OnSaveError(err)
+		}
+	}
+	for _, alert := range alerts {
+		b.limits.OnThreshold(alert)
+	}
+}
+
+// account adds the cost of a request to the spend of the key and returns the soft thresholds it crossed
+func (b *budget) account(key string, estimate, cost float64) []BudgetAlert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state(key)
+	state.pending -= estimate
+	if state.pending < 0 {
+		state.pending = 0
+	}
+	if cost <= 0 {
+		return nil
+	}
+
+	var alerts []BudgetAlert
+
+	alerts = append(alerts, b.crossed(key, BudgetPeriodDaily, state.Daily, state.Daily+cost, b.limits.Daily)...)
+	alerts = append(alerts, b.crossed(key, BudgetPeriodMonthly, state.Monthly, state.Monthly+cost, b.limits.Monthly)...)
+	alerts = append(alerts, b.crossed(key, BudgetPeriodTotal, state.Total, state.Total+cost, b.limits.Total)...)
+	state.Daily += cost
+	state.Monthly += cost
+	state.Total += cost
+	b.version++
+	return alerts
+}
+
+// crossed returns the alerts of the soft thresholds crossed by the spend going from before to after
+func (b *budget) crossed(key, period string, before, after, cap float64) []BudgetAlert {
+	if cap <= 0 || b.limits.OnThreshold == nil {
+		return nil
+	}
+	var alerts []BudgetAlert
+	for _, threshold := range b.limits.SoftThresholds {
+		if limit := threshold * cap; before < limit && after >= limit {
+			alerts = append(alerts, BudgetAlert{
+				Key:       key,
+				Period:    period,
+				Threshold: threshold,
+				Spent:     after,
+				Cap:       cap,
+			})
+		}
+	}
+	return alerts
+}
+
+// estimate returns the estimated cost of the call, which is zero for the requests that aren't priced
+// by tokens
+func (b *budget) estimate(call *Call) float64 {
+	estimator, ok := call.Payload.(tokenEstimator)
+	if !ok {
+		return 0
+	}
+	prompt, completion := estimator.estimateUsage()
+	return b.limits.Prices.Cost(callModel(call), prompt, completion)
+}
+
+// callModel returns the model of the call, or the engine of the requests made with an engine, whose
+// payload doesn't have a model
+func callModel(call *Call) string {
+	if model := call.Model(); model != "" {
+		return model
+	}
+	parts := strings.Split(call.Request.URL.Path, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "engines" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// middleware rejects calls with ErrBudgetExceeded once a cap is reached and accounts the spend of the
+// other calls
+func (b *budget) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(call *Call) (*http.Response, error) {
+		key, _ := call.Request.Context().Value(budgetKey{}).(string)
+		estimate := b.estimate(call)
+		if err := b.reserve(key, estimate); err != nil {
+			return nil, err
+		}
+
+		resp, err := next(call)
+		if err != nil {
+			b.settle(key, estimate, 0)
+			return nil, err
+		}
+		InspectResponse(resp, call.Stream, func(info ResponseInfo) {
+			cost := estimate
+			if info.PromptTokens > 0 || info.CompletionTokens > 0 {
+				model := info.Model
+				if model == "" {
+					model = callModel(call)
+				}
+				cost = b.limits.Prices.Cost(model, info.PromptTokens, info.CompletionTokens)
+			}
+			b.settle(key, estimate, cost)
+		})
+		return resp, nil
+	}
+}
@@ -0,0 +1,145 @@
+package gpt3
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// gpt-4 usage that costs $0.03 + $0.03 = $0.06
+const gpt4UsageResponse = `{
+	"model": "gpt-4-0314",
+	"choices": [{"index": 0, "finish_reason": "stop"}],
+	"usage": {"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500}
+}`
+
+func TestBudget(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 3, 31, 23, 0, 0, 0, time.UTC)}
+	var alerts []BudgetAlert
+	b := newBudget(Budget{
+		Daily:          1,
+		Monthly:        1.5,
+		SoftThresholds: []float64{0.5, 0.9},
+		OnThreshold: func(alert BudgetAlert) {
+			alerts = append(alerts, alert)
+		},
+	})
+	b.now = clock.Now
+
+	assert.NoError(t, b.reserve("", 0.4))
+	// the pending estimate counts against the caps until the request completes
+	assert.NoError(t, b.reserve("", 0.6))
+	assert.True(t, errors.Is(b.reserve("", 0.1), ErrBudgetExceeded))
+	b.settle("", 0.4, 0.5)
+	b.settle("", 0.6, 0)
+	assert.Equal(t, []BudgetAlert{
+		{Period: BudgetPeriodDaily, Threshold: 0.5, Spent: 0.5, Cap: 1},
+	}, alerts)
+
+	alerts = nil
+	assert.NoError(t, b.reserve("", 0.5))
+	b.settle("", 0.5, 0.5)
+	assert.Equal(t, []BudgetAlert{
+		{Period: BudgetPeriodDaily, Threshold: 0.9, Spent: 1, Cap: 1},
+		{Period: BudgetPeriodMonthly, Threshold: 0.5, Spent: 1, Cap: 1.5},
+	}, alerts)
+	err := b.reserve("", 0)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.EqualError(t, err, "budget exceeded: daily spend of $1.00 reached the cap of $1.00")
+
+	// other keys have their own budget
+	assert.NoError(t, b.reserve("team-b", 0))
+
+	// the daily and monthly spend are reset with the next month
+	clock.now = clock.now.Add(2 * time.Hour)
+	assert.NoError(t, b.reserve("", 0))
+	assert.Equal(t, 0.0, b.state("").Daily)
+	assert.Equal(t, 1.0, b.state("").Total)
+}
+
+func TestWithBudget(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gpt3")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "budget.json")
+
+	rt, httpClient := fakeHttpClient()
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return statusResponse(200, nil, gpt4UsageResponse), nil
+	}
+	budget := Budget{Total: 0.1, StateFile: stateFile}
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithBudget(budget))
+	request := ChatCompletionRequest{Model: "gpt-4", Messages: []ChatCompletionRequestMessage{{Content: "hello"}}}
+
+	_, err = client.ChatCompletion(ctx, request)
+	assert.NoError(t, err)
+	_, err = client.ChatCompletion(ctx, request)
+	assert.NoError(t, err)
+	_, err = client.ChatCompletion(ctx, request)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.Equal(t, 2, rt.RoundTripCallCount())
+
+	_, err = client.ChatCompletion(WithBudgetKey(ctx, "team-b"), request)
+	assert.NoError(t, err)
+
+	// the spend survives a restart
+	client = NewClient("test-key", WithHTTPClient(httpClient), WithBudget(budget))
+	_, err = client.ChatCompletion(ctx, request)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	_, err = client.ChatCompletion(WithBudgetKey(ctx, "team-b"), request)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, rt.RoundTripCallCount())
+
+	// a corrupted state fails every request
+	assert.NoError(t, ioutil.WriteFile(stateFile, []byte("{"), os.ModePerm))
+	client = NewClient("test-key", WithHTTPClient(httpClient), WithBudget(budget))
+	_, err = client.ChatCompletion(WithBudgetKey(ctx, "team-c"), request)
+	assert.EqualError(t, err, "invalid budget state: unexpected end of JSON input")
+	assert.Equal(t, 4, rt.RoundTripCallCount())
+}
+
+func TestBudgetSaveErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpt3")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stateDir := filepath.Join(dir, "state")
+
+	var saveErrs []error
+	b := newBudget(Budget{Total: 1, StateFile: filepath.Join(stateDir, "budget.json"), OnSaveError: func(err error) {
+		saveErrs = append(saveErrs, err)
+	}})
+
+	// a failed write is reported without failing the next requests
+	assert.NoError(t, b.reserve("", 0.1))
+	b.settle("", 0.1, 0.2)
+	assert.Len(t, saveErrs, 1)
+	assert.Contains(t, saveErrs[0].Error(), "failed writing budget state")
+	assert.NoError(t, b.reserve("", 0.1))
+
+	// and the state is written by the next settled request
+	assert.NoError(t, os.Mkdir(stateDir, os.ModePerm))
+	b.settle("", 0.1, 0.3)
+	assert.Len(t, saveErrs, 1)
+	restored := newBudget(Budget{StateFile: filepath.Join(stateDir, "budget.json")})
+	assert.NoError(t, restored.err)
+	assert.InDelta(t, 0.5, restored.state("").Total, 1e-9)
+}
+
+func TestBudgetEstimatesEngineCompletions(t *testing.T) {
+	b := newBudget(Budget{Total: 1})
+	req, err := http.NewRequest("POST", "https://api.openai.com/v1/engines/davinci/completions", nil)
+	assert.NoError(t, err)
+	call := &Call{Payload: CompletionRequest{Prompt: PromptString("hello"), MaxTokens: IntPtr(1000)}, Request: req}
+
+	assert.Equal(t, "davinci", callModel(call))
+	assert.InDelta(t, DefaultPriceTable.Cost("davinci", estimatePromptTokens(PromptString("hello")), 1000), b.estimate(call), 1e-9)
+	assert.True(t, b.estimate(call) > 0)
+}
@@ -51,10 +51,106 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 
 // WithTimeout is a client option that allows you to override the default timeout duration of requests
 // for the client. The default is 30 seconds. If you are overriding the http client as well, just include
-// the timeout there.
+// the timeout there. Streaming requests are exempt from it, see WithFirstEventTimeout and WithIdleTimeout
+// instead.
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(c *client) error {
 		c.httpClient.Timeout = timeout
 		return nil
 	}
 }
+
+// WithConnectTimeout is a client option that fails every attempt of a request whose connection, including
+// the TLS handshake, isn't established within the timeout with a *ConnectTimeoutError. The time waiting
+// for the response isn't counted, so it doesn't end slow completions. The attempt is retried according to
+// the retry policy. It relies on the httptrace connection events reported by http.Transport, and has no
+// effect with transports that don't report them.
+func WithConnectTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) error {
+		c.timeouts.connect = timeout
+		return nil
+	}
+}
+
+// WithFirstEventTimeout is a client option that fails streaming requests whose first data isn't received
+// within the timeout, counted from when the request is sent, with a *FirstEventTimeoutError. The attempt
+// is retried according to the retry policy. Without it, the idle timeout applies to the first data too.
+// StreamFineTuneEvents is exempt, as it is quiet while the job is queued.
+func WithFirstEventTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) error {
+		c.timeouts.firstEvent = timeout
+		return nil
+	}
+}
+
+// WithIdleTimeout is a client option that ends streams receiving no data for longer than the timeout with
+// an *IdleTimeoutError. The default is 30 seconds, and 0 disables it. StreamFineTuneEvents is exempt, as
+// it is quiet for minutes while the job is queued or running.
+func WithIdleTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) error {
+		c.timeouts.idle = timeout
+		return nil
+	}
+}
+
+// WithModerationGuard is a client option that screens the prompt of every completion request with the
+// moderations API before sending it. With ScreenOutput set, the generated text of non-streamed completions
+// is screened as well before it is returned. Flagged content fails the call with a *ModerationBlockedError.
+func WithModerationGuard(options ModerationGuardOptions) ClientOption {
+	return func(c *client) error {
+		c.moderationGuard = &options
+		return nil
+	}
+}
+
+// WithRetryPolicy is a client option that retries requests failing with a retryable status code or a
+// transient connection error, with an exponential backoff between attempts. The Retry-After header of a
+// failed response takes precedence over the backoff. Streaming requests are only retried until the
+// stream starts.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *client) error {
+		c.retryPolicy = &policy
+		return nil
+	}
+}
+
+// WithRateLimit is a client option that holds back requests to stay within the given requests and tokens
+// per minute, shared by all the goroutines using the client. Every attempt of a request counts against
+// the limits, including retries.
+func WithRateLimit(limit RateLimit) ClientOption {
+	return func(c *client) error {
+		c.rateLimiter = newRateLimiter(limit)
+		return nil
+	}
+}
+
+// WithMiddleware is a client option that wraps every call made by the client, including file uploads and
+// streaming calls. Middleware runs once per call, outside of the rate limiter and the retry policy, and
+// the middleware added first is the outermost one.
+func WithMiddleware(middleware func(next RoundTripFunc) RoundTripFunc) ClientOption {
+	return func(c *client) error {
+		c.middleware = append(c.middleware, middleware)
+		return nil
+	}
+}
+
+// WithUsageTracker is a client option that records the usage of every successful call of the client with
+// the tracker. The tracker can be shared by several clients.
+func WithUsageTracker(tracker *UsageTracker) ClientOption {
+	return func(c *client) error {
+		c.middleware = append(c.middleware, tracker.middleware)
+		return nil
+	}
+}
+
+// WithBudget is a client option that rejects new requests with ErrBudgetExceeded once the spend of the
+// client reaches a cap of the budget, and tracks it separately for every key set with WithBudgetKey. When
+// the state file of the budget can't be read, every request fails with that error instead. Errors writing
+// the state file don't fail requests, they are reported to Budget.OnSaveError.
+func WithBudget(budget Budget) ClientOption {
+	return func(c *client) error {
+		b := newBudget(budget)
+		c.middleware = append(c.middleware, b.middleware)
+		return b.err
+	}
+}
@@ -22,7 +22,7 @@ func main() {
 	client := gpt3.NewClient(apiKey)
 
 	resp, err := client.Completion(ctx, gpt3.CompletionRequest{
-		Prompt:    "1\n2\n3\n4",
+		Prompt:    gpt3.PromptString("1\n2\n3\n4"),
 		MaxTokens: gpt3.IntPtr(0),
 	})
 	if err != nil {
@@ -2,9 +2,9 @@ module example.com/main
 
 go 1.18
 
-replace github.com/PullRequestInc/go-gpt3 => ../../
+replace github.com/alexandrubordei/go-gpt3 => ../../
 
 require (
-	github.com/PullRequestInc/go-gpt3 v0.0.0-00010101000000-000000000000
+	github.com/alexandrubordei/go-gpt3 v0.0.0-00010101000000-000000000000
 	github.com/joho/godotenv v1.4.0
 )
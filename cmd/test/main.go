@@ -6,7 +6,7 @@ import (
 	"log"
 	"os"
 
-	"github.com/PullRequestInc/go-gpt3"
+	"github.com/alexandrubordei/go-gpt3"
 	"github.com/joho/godotenv"
 )
 
@@ -22,9 +22,7 @@ func main() {
 	client := gpt3.NewClient(apiKey)
 
 	resp, err := client.Completion(ctx, gpt3.CompletionRequest{
-		Prompt: []string{
-			"1\n2\n3\n4",
-		},
+		Prompt:    gpt3.PromptString("1\n2\n3\n4"),
 		MaxTokens: gpt3.IntPtr(10),
 	})
 	if err != nil {
@@ -33,21 +31,34 @@ func main() {
 	log.Printf("%+v\n", resp)
 
 	resp, err = client.Completion(ctx, gpt3.CompletionRequest{
-		Prompt: []string{
-			"go:golang\npy:python\njs:",
-		},
-		Stop: []string{"\n"},
+		Prompt: gpt3.PromptString("go:golang\npy:python\njs:"),
+		Stop:   []string{"\n"},
 	})
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	fmt.Print("\n\nbatched prompts:\n")
+
+	resp, err = client.Completion(ctx, gpt3.CompletionRequest{
+		Prompt: gpt3.PromptStrings(
+			"The capital of France is",
+			"The capital of Japan is",
+		),
+		MaxTokens: gpt3.IntPtr(5),
+		N:         gpt3.IntPtr(2),
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, choice := range resp.Choices {
+		fmt.Printf("prompt %d: %s\n", choice.PromptIndex, choice.Text)
+	}
+
 	fmt.Print("\n\nstarting stream:\n")
 
 	request := gpt3.CompletionRequest{
-		Prompt: []string{
-			"One thing that you should know about golang",
-		},
+		Prompt:    gpt3.PromptString("One thing that you should know about golang"),
 		MaxTokens: gpt3.IntPtr(20),
 	}
 
@@ -0,0 +1,102 @@
+package gpt3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by APIError with errors.Is
+var (
+	// ErrRateLimited is matched by 429 responses caused by sending requests or tokens too fast
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnauthorized is matched by 401 responses, usually caused by an invalid api key or organization
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrQuotaExceeded is matched when the organization ran out of credits or reached its hard limit
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrContextLengthExceeded is matched when the prompt and completion exceed the context length of the model
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	// ErrModelNotFound is matched when the requested model does not exist or isn't accessible
+	ErrModelNotFound = errors.New("model not found")
+	// ErrServerError is matched by 5xx responses
+	ErrServerError = errors.New("server error")
+)
+
+// ErrBudgetExceeded is returned instead of sending a request once a cap of the budget set with WithBudget
+// is reached
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Errors returned while reading streamed responses
+var (
+	// ErrStreamTruncated is returned when the response body of a stream ends before the stream was
+	// terminated by the API, so the streamed results are incomplete
+	ErrStreamTruncated = errors.New("stream truncated")
+	// ErrStreamClosed is returned by Stream.Recv after the stream was closed
+	ErrStreamClosed = errors.New("stream closed")
+)
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("[%d:%s] %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Is lets errors.Is match an APIError against the sentinel errors of this package.
+func (e APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests && !e.isQuotaExceeded()
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrQuotaExceeded:
+		return e.isQuotaExceeded()
+	case ErrContextLengthExceeded:
+		return e.Code == "context_length_exceeded" ||
+			(e.StatusCode == http.StatusBadRequest && strings.Contains(e.Message, "maximum context length"))
+	case ErrModelNotFound:
+		return e.Code == "model_not_found" ||
+			(e.StatusCode == http.StatusNotFound && strings.Contains(e.Message, "model") && strings.Contains(e.Message, "does not exist"))
+	case ErrServerError:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+func (e APIError) isQuotaExceeded() bool {
+	return e.Code == "insufficient_quota" || e.Type == "insufficient_quota"
+}
+
+// TransportError is returned when a request failed before a response was received, or while its body
+// was being read. It wraps the underlying error, which is usually an *url.Error or a net.Error.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable returns whether err is worth retrying: rate limits (but not exceeded quotas), server errors
+// and transient connection errors. Context cancellation is never retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return false
+	}
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range defaultRetryableStatusCodes {
+			if apiErr.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return isTransientError(transportErr.Err)
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package gpt3
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Fine-tune job statuses
+const (
+	FineTuneStatusPending   = "pending"
+	FineTuneStatusRunning   = "running"
+	FineTuneStatusSucceeded = "succeeded"
+	FineTuneStatusFailed    = "failed"
+	FineTuneStatusCancelled = "cancelled"
+)
+
+const (
+	defaultFineTunePollInterval = 10 * time.Second
+)
+
+// WaitForFineTuneOptions configures how WaitForFineTune polls a fine-tune job
+type WaitForFineTuneOptions struct {
+	// PollInterval is the delay between two polls of the job. Defaults to 10 seconds.
+	PollInterval time.Duration
+	// BackoffFactor multiplies the delay after every poll that didn't report any new events. The delay
+	// goes back to PollInterval as soon as new events arrive. Values below 1 disable the backoff.
+	BackoffFactor float64
+	// MaxPollInterval caps the delay between two polls when backing off. Defaults to PollInterval.
+	MaxPollInterval time.Duration
+	// OnEvent is called exactly once for every new event of the job, in the order they were created.
+	OnEvent func(Event)
+	// OnStatus is called with the job every time its status changes.
+	OnStatus func(*FineTuneResponse)
+}
+
+// FineTuneError is returned by WaitForFineTune when a job ends with the failed or cancelled status
+type FineTuneError struct {
+	ID       string
+	Status   string
+	FineTune *FineTuneResponse
+}
+
+func (e *FineTuneError) Error() string {
+	return fmt.Sprintf("fine-tune %s %s", e.ID, e.Status)
+}
+
+func isFineTuneFinished(status string) bool {
+	switch status {
+	case FineTuneStatusSucceeded, FineTuneStatusFailed, FineTuneStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// WaitForFineTune polls the fine-tune job until it succeeds, fails or is cancelled.
+func (c *client) WaitForFineTune(ctx context.Context, jobId string, opts WaitForFineTuneOptions) (*FineTuneResponse, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultFineTunePollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+
+	delay := interval
+	seenEvents := 0
+	status := ""
+	var fineTune *FineTuneResponse
+	for {
+		rsp, err := c.GetFineTune(ctx, jobId)
+		if err != nil {
+			return fineTune, err
+		}
+		fineTune = rsp
+
+		var newEvents []Event
+		if seenEvents < len(fineTune.Events) {
+			newEvents = fineTune.Events[seenEvents:]
+		}
+		for _, event := range newEvents {
+			if opts.OnEvent != nil {
+				opts.OnEvent(event)
+			}
+		}
+		seenEvents += len(newEvents)
+
+		if fineTune.Status != status {
+			status = fineTune.Status
+			if opts.OnStatus != nil {
+				opts.OnStatus(fineTune)
+			}
+		}
+
+		if isFineTuneFinished(status) {
+			if status != FineTuneStatusSucceeded {
+				return fineTune, &FineTuneError{ID: jobId, Status: status, FineTune: fineTune}
+			}
+			return fineTune, nil
+		}
+
+		if len(newEvents) > 0 || opts.BackoffFactor < 1 {
+			delay = interval
+		} else {
+			delay = time.Duration(float64(delay) * opts.BackoffFactor)
+			if delay > maxInterval {
+				delay = maxInterval
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fineTune, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -33,6 +35,24 @@ const (
 	defaultTimeoutSeconds = 30
 )
 
+// Chat Models
+const (
+	GPT3Dot5Turbo     = "gpt-3.5-turbo"
+	GPT3Dot5Turbo0301 = "gpt-3.5-turbo-0301"
+	DefaultChatModel  = GPT3Dot5Turbo
+)
+
+// Audio Models
+const (
+	Whisper1 = "whisper-1"
+)
+
+// Moderation Models
+const (
+	TextModerationLatest = "text-moderation-latest"
+	TextModerationStable = "text-moderation-stable"
+)
+
 const (
 	FineTunePurpose        = "fine-tune"
 	SearchPurpose          = "search"
@@ -73,18 +93,35 @@ func getEngineURL(engine string) string {
 type Client interface {
 	// Engines lists the currently available engines, and provides basic information about each
 	// option such as the owner and availability.
+	//
+	// Deprecated: the engines endpoints are deprecated by OpenAI, use ListModels instead.
 	Engines(ctx context.Context) (*EnginesResponse, error)
 
 	// Engine retrieves an engine instance, providing basic information about the engine such
 	// as the owner and availability.
+	//
+	// Deprecated: the engines endpoints are deprecated by OpenAI, use GetModel instead.
 	Engine(ctx context.Context, engine string) (*EngineObject, error)
 
+	// ListModels lists the currently available models, and provides basic information about each one
+	// such as the owner and permissions.
+	ListModels(ctx context.Context) (*ModelsResponse, error)
+
+	// GetModel retrieves a model instance, providing basic information about the model such as the
+	// owner and permissions.
+	GetModel(ctx context.Context, model string) (*Model, error)
+
+	// DeleteModel deletes a fine-tuned model. You must have the Owner role in your organization.
+	DeleteModel(ctx context.Context, model string) (*ModelDeleteResponse, error)
+
 	// Completion creates a completion with the default engine. This is the main endpoint of the API
-	// which auto-completes based on the given prompt.
+	// which auto-completes based on the given prompt. If the request sets a Model, the model is sent in
+	// the request body to the /completions endpoint instead of using the default engine.
 	Completion(ctx context.Context, request CompletionRequest) (*CompletionResponse, error)
 
 	// CompletionStream creates a completion with the default engine and streams the results through
-	// multiple calls to onData.
+	// multiple calls to onData. As with Completion, setting a Model on the request uses the /completions
+	// endpoint instead of the default engine.
 	CompletionStream(ctx context.Context, request CompletionRequest, onData func(*CompletionResponse)) error
 
 	// CompletionWithEngine is the same as Completion except allows overriding the default engine on the client
@@ -93,6 +130,27 @@ type Client interface {
 	// CompletionStreamWithEngine is the same as CompletionStream except allows overriding the default engine on the client
 	CompletionStreamWithEngine(ctx context.Context, engine string, request CompletionRequest, onData func(*CompletionResponse)) error
 
+	// CreateCompletionStream creates a completion with the default engine and returns a Stream to receive
+	// its results from. As with Completion, setting a Model on the request uses the /completions endpoint
+	// instead of the default engine. The stream must be closed.
+	CreateCompletionStream(ctx context.Context, request CompletionRequest) (*Stream, error)
+
+	// CreateCompletionStreamWithEngine is the same as CreateCompletionStream except allows overriding the default engine on the client
+	CreateCompletionStreamWithEngine(ctx context.Context, engine string, request CompletionRequest) (*Stream, error)
+
+	// CompletionStreamReader creates a completion like CreateCompletionStream and returns a reader of the
+	// text of its first choice as it arrives. Closing the reader cancels the request. Use NewChoiceReader to
+	// read another choice of a Stream.
+	CompletionStreamReader(ctx context.Context, request CompletionRequest) (io.ReadCloser, error)
+
+	// ChatCompletion creates a completion for the chat messages in the request. If no model is set
+	// on the request the DefaultChatModel is used.
+	ChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error)
+
+	// ChatCompletionStream creates a completion for the chat messages in the request and streams the
+	// message deltas through multiple calls to onData.
+	ChatCompletionStream(ctx context.Context, request ChatCompletionRequest, onData func(*ChatCompletionStreamResponse)) error
+
 	// Given a prompt and an instruction, the model will return an edited version of the prompt.
 	Edits(ctx context.Context, request EditsRequest) (*EditsResponse, error)
 
@@ -102,12 +160,30 @@ type Client interface {
 	// SearchWithEngine performs a semantic search over a list of documents with the specified engine.
 	SearchWithEngine(ctx context.Context, engine string, request SearchRequest) (*SearchResponse, error)
 
+	// Answers answers the specified question using the provided documents or uploaded file and examples.
+	Answers(ctx context.Context, request AnswersRequest) (*AnswersResponse, error)
+
+	// Classifications classifies the specified query using the provided examples or uploaded file.
+	Classifications(ctx context.Context, request ClassificationsRequest) (*ClassificationsResponse, error)
+
 	//UploadFile Uploads a file that contains document(s) to be used across various endpoints/features.
 	UploadFile(ctx context.Context, filename string, purpose string) (*FileUploadResponse, error)
 
 	//DeleteFile deletes a file from the server-side storage identified by id
 	DeleteFile(ctx context.Context, fileId string) (*FileDeleteResponse, error)
 
+	// ListFiles returns a list of files that belong to the user's organization. When purpose is not
+	// empty only files uploaded with that purpose are returned.
+	ListFiles(ctx context.Context, purpose string) (*FilesResponse, error)
+
+	// GetFile returns information about the file identified by id
+	GetFile(ctx context.Context, fileId string) (*File, error)
+
+	// DownloadFileContent returns the contents of the file identified by id. The caller is responsible
+	// for closing the returned reader. The download is exempt from the timeout of the http client, so
+	// large files can be read slowly; use the context to bound it.
+	DownloadFileContent(ctx context.Context, fileId string) (io.ReadCloser, error)
+
 	//CreateFineTune Creates a job that fine-tunes a specified model from a given dataset.
 	CreateFineTune(ctx context.Context, fileId string) (*FineTuneResponse, error)
 
@@ -117,16 +193,58 @@ type Client interface {
 	//GetFineTune Gets info about the fine-tune job.
 	GetFineTune(ctx context.Context, id string) (*FineTuneResponse, error)
 
+	// ListFineTunes lists the organization's fine-tuning jobs.
+	ListFineTunes(ctx context.Context) (*FineTunesResponse, error)
+
+	// CancelFineTune immediately cancels a fine-tune job.
+	CancelFineTune(ctx context.Context, id string) (*FineTuneResponse, error)
+
+	// WaitForFineTune polls a fine-tune job until its status becomes succeeded, failed or cancelled and
+	// returns the final job. New events are reported exactly once through opts.OnEvent. A *FineTuneError is
+	// returned alongside the job when it failed or was cancelled.
+	WaitForFineTune(ctx context.Context, id string, opts WaitForFineTuneOptions) (*FineTuneResponse, error)
+
+	// ListFineTuneEvents gets the fine-grained status updates for a fine-tune job.
+	ListFineTuneEvents(ctx context.Context, id string) (*FineTuneEventsResponse, error)
+
+	// StreamFineTuneEvents streams the status updates for a fine-tune job through multiple calls to
+	// onEvent as they become available. The stream is closed by the server once the job finishes.
+	StreamFineTuneEvents(ctx context.Context, id string, onEvent func(*Event)) error
+
 	CreateEmbeddings(ctx context.Context, model string, input []string) (*EmbeddingsResponse, error)
+
+	// Moderation classifies whether the input text violates OpenAI's content policy.
+	Moderation(ctx context.Context, request ModerationRequest) (*ModerationResponse, error)
+
+	// CreateImage creates images given a prompt.
+	CreateImage(ctx context.Context, request ImageRequest) (*ImageResponse, error)
+
+	// CreateImageEdit creates edited or extended images given an original image and a prompt.
+	CreateImageEdit(ctx context.Context, request ImageEditRequest) (*ImageResponse, error)
+
+	// CreateImageVariation creates variations of a given image.
+	CreateImageVariation(ctx context.Context, request ImageVariationRequest) (*ImageResponse, error)
+
+	// CreateTranscription transcribes audio into the input language.
+	CreateTranscription(ctx context.Context, request AudioRequest) (*AudioResponse, error)
+
+	// CreateTranslation translates audio into English. The Language of the request must be empty, as the
+	// translations API always outputs English.
+	CreateTranslation(ctx context.Context, request AudioRequest) (*AudioResponse, error)
 }
 
 type client struct {
-	baseURL       string
-	apiKey        string
-	userAgent     string
-	httpClient    *http.Client
-	defaultEngine string
-	idOrg         string
+	baseURL         string
+	apiKey          string
+	userAgent       string
+	httpClient      *http.Client
+	defaultEngine   string
+	idOrg           string
+	moderationGuard *ModerationGuardOptions
+	retryPolicy     *RetryPolicy
+	rateLimiter     *rateLimiter
+	middleware      []Middleware
+	timeouts        timeouts
 }
 
 // NewClient returns a new OpenAI GPT-3 API client. An apiKey is required to use the client
@@ -142,6 +260,7 @@ func NewClient(apiKey string, options ...ClientOption) Client {
 		httpClient:    httpClient,
 		defaultEngine: DefaultEngine,
 		idOrg:         "",
+		timeouts:      timeouts{idle: defaultIdleTimeout},
 	}
 	for _, o := range options {
 		o(c)
@@ -150,7 +269,7 @@ func NewClient(apiKey string, options ...ClientOption) Client {
 }
 
 func (c *client) Engines(ctx context.Context) (*EnginesResponse, error) {
-	req, err := c.newRequest(ctx, "GET", "/engines", nil)
+	req, err := c.newRequest(ctx, "Engines", "GET", "/engines", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +286,7 @@ func (c *client) Engines(ctx context.Context) (*EnginesResponse, error) {
 }
 
 func (c *client) Engine(ctx context.Context, engine string) (*EngineObject, error) {
-	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/engines/%s", engine), nil)
+	req, err := c.newRequest(ctx, "Engine", "GET", fmt.Sprintf("/engines/%s", engine), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -183,13 +302,74 @@ func (c *client) Engine(ctx context.Context, engine string) (*EngineObject, erro
 	return output, nil
 }
 
+func (c *client) ListModels(ctx context.Context) (*ModelsResponse, error) {
+	req, err := c.newRequest(ctx, "ListModels", "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(ModelsResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *client) GetModel(ctx context.Context, model string) (*Model, error) {
+	req, err := c.newRequest(ctx, "GetModel", "GET", fmt.Sprintf("/models/%s", model), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(Model)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *client) DeleteModel(ctx context.Context, model string) (*ModelDeleteResponse, error) {
+	req, err := c.newRequest(ctx, "DeleteModel", "DELETE", fmt.Sprintf("/models/%s", model), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(ModelDeleteResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
 func (c *client) Completion(ctx context.Context, request CompletionRequest) (*CompletionResponse, error) {
-	return c.CompletionWithEngine(ctx, c.defaultEngine, request)
+	if request.Model != "" {
+		return c.completion(ctx, "Completion", "/completions", request)
+	}
+	return c.completion(ctx, "Completion", fmt.Sprintf("/engines/%s/completions", c.defaultEngine), request)
 }
 
 func (c *client) CompletionWithEngine(ctx context.Context, engine string, request CompletionRequest) (*CompletionResponse, error) {
+	return c.completion(ctx, "CompletionWithEngine", fmt.Sprintf("/engines/%s/completions", engine), request)
+}
+
+func (c *client) completion(ctx context.Context, operation, path string, request CompletionRequest) (*CompletionResponse, error) {
+	if err := c.screenPrompt(ctx, request); err != nil {
+		return nil, err
+	}
 	request.Stream = false
-	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/engines/%s/completions", engine), request)
+	req, err := c.newRequest(ctx, operation, "POST", path, request)
 	if err != nil {
 		return nil, err
 	}
@@ -202,64 +382,146 @@ func (c *client) CompletionWithEngine(ctx context.Context, engine string, reques
 	if err := getResponseObject(resp, output); err != nil {
 		return nil, err
 	}
+	setPromptIndexes(output.Choices, request.choicesPerPrompt())
+	if err := c.screenCompletion(ctx, output); err != nil {
+		return nil, err
+	}
 	return output, nil
 }
 
 func (c *client) CompletionStream(ctx context.Context, request CompletionRequest, onData func(*CompletionResponse)) error {
-	return c.CompletionStreamWithEngine(ctx, c.defaultEngine, request, onData)
+	if request.Model != "" {
+		return c.completionStream(ctx, "CompletionStream", "/completions", request, onData)
+	}
+	return c.completionStream(ctx, "CompletionStream", fmt.Sprintf("/engines/%s/completions", c.defaultEngine), request, onData)
 }
 
-var dataPrefix = []byte("data: ")
-var doneSequence = []byte("[DONE]")
-
 func (c *client) CompletionStreamWithEngine(
 	ctx context.Context,
 	engine string,
 	request CompletionRequest,
 	onData func(*CompletionResponse),
 ) error {
-	request.Stream = true
-	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/engines/%s/completions", engine), request)
-	if err != nil {
-		return err
-	}
-	resp, err := c.performRequest(req)
+	return c.completionStream(ctx, "CompletionStreamWithEngine", fmt.Sprintf("/engines/%s/completions", engine), request, onData)
+}
+
+func (c *client) completionStream(
+	ctx context.Context,
+	operation, path string,
+	request CompletionRequest,
+	onData func(*CompletionResponse),
+) error {
+	stream, err := c.createCompletionStream(ctx, operation, path, request)
 	if err != nil {
 		return err
 	}
-
-	reader := bufio.NewReader(resp.Body)
-	defer resp.Body.Close()
+	defer stream.Close()
 
 	for {
-		line, err := reader.ReadBytes('\n')
+		output, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
-		// make sure there isn't any extra whitespace before or after
-		line = bytes.TrimSpace(line)
-		// the completion API only returns data events
-		if !bytes.HasPrefix(line, dataPrefix) {
-			continue
-		}
-		line = bytes.TrimPrefix(line, dataPrefix)
+		onData(output)
+	}
+}
 
-		// the stream is completed when terminated by [DONE]
-		if bytes.HasPrefix(line, doneSequence) {
-			break
-		}
-		output := new(CompletionResponse)
-		if err := json.Unmarshal(line, output); err != nil {
+func (c *client) CreateCompletionStream(ctx context.Context, request CompletionRequest) (*Stream, error) {
+	if request.Model != "" {
+		return c.createCompletionStream(ctx, "CreateCompletionStream", "/completions", request)
+	}
+	return c.createCompletionStream(ctx, "CreateCompletionStream", fmt.Sprintf("/engines/%s/completions", c.defaultEngine), request)
+}
+
+func (c *client) CreateCompletionStreamWithEngine(ctx context.Context, engine string, request CompletionRequest) (*Stream, error) {
+	return c.createCompletionStream(ctx, "CreateCompletionStreamWithEngine", fmt.Sprintf("/engines/%s/completions", engine), request)
+}
+
+func (c *client) CompletionStreamReader(ctx context.Context, request CompletionRequest) (io.ReadCloser, error) {
+	if request.Model != "" {
+		return c.completionStreamReader(ctx, "/completions", request)
+	}
+	return c.completionStreamReader(ctx, fmt.Sprintf("/engines/%s/completions", c.defaultEngine), request)
+}
+
+func (c *client) completionStreamReader(ctx context.Context, path string, request CompletionRequest) (io.ReadCloser, error) {
+	stream, err := c.createCompletionStream(ctx, "CompletionStreamReader", path, request)
+	if err != nil {
+		return nil, err
+	}
+	return NewChoiceReader(stream, 0), nil
+}
+
+func (c *client) createCompletionStream(ctx context.Context, operation, path string, request CompletionRequest) (*Stream, error) {
+	if err := c.screenPrompt(ctx, request); err != nil {
+		return nil, err
+	}
+	request.Stream = true
+	// closing the stream cancels the request
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := c.newRequest(ctx, operation, "POST", path, request)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := c.performCall(req, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return newStream(resp, cancel, request.choicesPerPrompt()), nil
+}
+
+func (c *client) ChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if request.Model == "" {
+		request.Model = DefaultChatModel
+	}
+	request.Stream = false
+	req, err := c.newRequest(ctx, "ChatCompletion", "POST", "/chat/completions", request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(ChatCompletionResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *client) ChatCompletionStream(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	onData func(*ChatCompletionStreamResponse),
+) error {
+	if request.Model == "" {
+		request.Model = DefaultChatModel
+	}
+	request.Stream = true
+	req, err := c.newRequest(ctx, "ChatCompletionStream", "POST", "/chat/completions", request)
+	if err != nil {
+		return err
+	}
+
+	return c.performStreamRequest(req, func(data []byte) error {
+		output := new(ChatCompletionStreamResponse)
+		if err := json.Unmarshal(data, output); err != nil {
 			return fmt.Errorf("invalid json stream data: %v", err)
 		}
 		onData(output)
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (c *client) Edits(ctx context.Context, request EditsRequest) (*EditsResponse, error) {
-	req, err := c.newRequest(ctx, "POST", "/edits", request)
+	req, err := c.newRequest(ctx, "Edits", "POST", "/edits", request)
 	if err != nil {
 		return nil, err
 	}
@@ -276,11 +538,15 @@ func (c *client) Edits(ctx context.Context, request EditsRequest) (*EditsRespons
 }
 
 func (c *client) Search(ctx context.Context, request SearchRequest) (*SearchResponse, error) {
-	return c.SearchWithEngine(ctx, c.defaultEngine, request)
+	return c.search(ctx, "Search", c.defaultEngine, request)
 }
 
 func (c *client) SearchWithEngine(ctx context.Context, engine string, request SearchRequest) (*SearchResponse, error) {
-	req, err := c.newRequest(ctx, "POST", fmt.Sprintf("/engines/%s/search", engine), request)
+	return c.search(ctx, "SearchWithEngine", engine, request)
+}
+
+func (c *client) search(ctx context.Context, operation, engine string, request SearchRequest) (*SearchResponse, error) {
+	req, err := c.newRequest(ctx, operation, "POST", fmt.Sprintf("/engines/%s/search", engine), request)
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +561,40 @@ func (c *client) SearchWithEngine(ctx context.Context, engine string, request Se
 	return output, nil
 }
 
+// Answers answers the specified question using the provided documents or uploaded file and examples.
+func (c *client) Answers(ctx context.Context, request AnswersRequest) (*AnswersResponse, error) {
+	req, err := c.newRequest(ctx, "Answers", "POST", "/answers", request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(AnswersResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// Classifications classifies the specified query using the provided examples or uploaded file.
+func (c *client) Classifications(ctx context.Context, request ClassificationsRequest) (*ClassificationsResponse, error) {
+	req, err := c.newRequest(ctx, "Classifications", "POST", "/classifications", request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(ClassificationsResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
 //UploadFile Uploads a file that contains document(s) to be used across various endpoints/features.
 func (c *client) UploadFile(ctx context.Context, filename string, purpose string) (*FileUploadResponse, error) {
 
@@ -305,45 +605,68 @@ func (c *client) UploadFile(ctx context.Context, filename string, purpose string
 
 	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("file", file.Name())
+	req, err := c.newMultipartRequest(ctx, "UploadFile", "/files", FileUploadRequest{File: filename, Purpose: purpose}, func(writer *multipart.Writer) error {
+		if err := writeFormFile(writer, "file", file.Name(), file); err != nil {
+			return err
+		}
+		return writeFormField(writer, "purpose", purpose)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	io.Copy(part, file)
-
-	part, err = writer.CreateFormField("purpose")
+	resp, err := c.performRequest(req)
 	if err != nil {
 		return nil, err
 	}
-	part.Write([]byte(purpose))
-	writer.Close()
+	output := new(FileUploadResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
 
-	url := c.baseURL + "/files"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+//DeleteFile deletes a file
+func (c *client) DeleteFile(ctx context.Context, fileId string) (*FileDeleteResponse, error) {
+	req, err := c.newRequest(ctx, "DeleteFile", "DELETE", fmt.Sprintf("/files/%s", fileId), nil)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(FileDeleteResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
 
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
+// ListFiles returns the files that belong to the user's organization, optionally filtered by purpose
+func (c *client) ListFiles(ctx context.Context, purpose string) (*FilesResponse, error) {
+	path := "/files"
+	if purpose != "" {
+		path += "?" + url.Values{"purpose": []string{purpose}}.Encode()
+	}
+	req, err := c.newRequest(ctx, "ListFiles", "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.performRequest(req)
 	if err != nil {
 		return nil, err
 	}
-	output := new(FileUploadResponse)
+	output := new(FilesResponse)
 	if err := getResponseObject(resp, output); err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
-//DeleteFile deletes a file
-func (c *client) DeleteFile(ctx context.Context, fileId string) (*FileDeleteResponse, error) {
-	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/files/%s", fileId), nil)
+// GetFile returns information about a file
+func (c *client) GetFile(ctx context.Context, fileId string) (*File, error) {
+	req, err := c.newRequest(ctx, "GetFile", "GET", fmt.Sprintf("/files/%s", fileId), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -351,25 +674,41 @@ func (c *client) DeleteFile(ctx context.Context, fileId string) (*FileDeleteResp
 	if err != nil {
 		return nil, err
 	}
-	output := new(FileDeleteResponse)
+	output := new(File)
 	if err := getResponseObject(resp, output); err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
+// DownloadFileContent streams the contents of a file
+func (c *client) DownloadFileContent(ctx context.Context, fileId string) (io.ReadCloser, error) {
+	req, err := c.newRequest(withoutClientTimeout(ctx), "DownloadFileContent", "GET", fmt.Sprintf("/files/%s/content", fileId), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
 //CreateFineTune Creates a job that fine-tunes a specified model from a given dataset.
 func (c *client) CreateFineTune(ctx context.Context, training_file string) (*FineTuneResponse, error) {
 	payload := FineTuneOptions{
 		TrainingFile: training_file,
 	}
-	return c.CreateFineTuneWithOptions(ctx, payload)
+	return c.createFineTune(ctx, "CreateFineTune", payload)
 }
 
 //CreateFineTuneWithOptions Creates a job that fine-tunes a specified model from a given dataset.
 func (c *client) CreateFineTuneWithOptions(ctx context.Context, fineTuneOptions FineTuneOptions) (*FineTuneResponse, error) {
+	return c.createFineTune(ctx, "CreateFineTuneWithOptions", fineTuneOptions)
+}
 
-	req, err := c.newRequest(ctx, "POST", "/fine-tunes", fineTuneOptions)
+func (c *client) createFineTune(ctx context.Context, operation string, fineTuneOptions FineTuneOptions) (*FineTuneResponse, error) {
+	req, err := c.newRequest(ctx, operation, "POST", "/fine-tunes", fineTuneOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -386,7 +725,41 @@ func (c *client) CreateFineTuneWithOptions(ctx context.Context, fineTuneOptions
 
 //GetFineTune Gets info about the fine-tune job.
 func (c *client) GetFineTune(ctx context.Context, jobId string) (*FineTuneResponse, error) {
-	req, err := c.newRequest(ctx, "GET", fmt.Sprintf("/fine-tunes/%s", jobId), nil)
+	req, err := c.newRequest(ctx, "GetFineTune", "GET", fmt.Sprintf("/fine-tunes/%s", jobId), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(FineTuneResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// ListFineTunes lists the organization's fine-tuning jobs.
+func (c *client) ListFineTunes(ctx context.Context) (*FineTunesResponse, error) {
+	req, err := c.newRequest(ctx, "ListFineTunes", "GET", "/fine-tunes", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(FineTunesResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// CancelFineTune immediately cancels a fine-tune job.
+func (c *client) CancelFineTune(ctx context.Context, jobId string) (*FineTuneResponse, error) {
+	req, err := c.newRequest(ctx, "CancelFineTune", "POST", fmt.Sprintf("/fine-tunes/%s/cancel", jobId), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -401,6 +774,41 @@ func (c *client) GetFineTune(ctx context.Context, jobId string) (*FineTuneRespon
 	return output, nil
 }
 
+// ListFineTuneEvents gets the status updates for a fine-tune job.
+func (c *client) ListFineTuneEvents(ctx context.Context, jobId string) (*FineTuneEventsResponse, error) {
+	req, err := c.newRequest(ctx, "ListFineTuneEvents", "GET", fmt.Sprintf("/fine-tunes/%s/events", jobId), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	output := new(FineTuneEventsResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// StreamFineTuneEvents streams the status updates for a fine-tune job. The stream is exempt from the
+// first event and idle timeouts, as no event is sent for minutes while the job is queued or running.
+func (c *client) StreamFineTuneEvents(ctx context.Context, jobId string, onEvent func(*Event)) error {
+	req, err := c.newRequest(withoutStreamTimeouts(ctx), "StreamFineTuneEvents", "GET", fmt.Sprintf("/fine-tunes/%s/events?stream=true", jobId), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.performStreamRequest(req, func(data []byte) error {
+		output := new(Event)
+		if err := json.Unmarshal(data, output); err != nil {
+			return fmt.Errorf("invalid json stream data: %v", err)
+		}
+		onEvent(output)
+		return nil
+	})
+}
+
 //CreateEmbeddings Creates an embedding vector representing the input text.
 func (c *client) CreateEmbeddings(ctx context.Context, model string, input []string) (*EmbeddingsResponse, error) {
 
@@ -409,7 +817,7 @@ func (c *client) CreateEmbeddings(ctx context.Context, model string, input []str
 		Input: input,
 	}
 
-	req, err := c.newRequest(ctx, "POST", "/embeddings", payload)
+	req, err := c.newRequest(ctx, "CreateEmbeddings", "POST", "/embeddings", payload)
 	if err != nil {
 		return nil, err
 	}
@@ -426,15 +834,201 @@ func (c *client) CreateEmbeddings(ctx context.Context, model string, input []str
 	return output, nil
 }
 
-func (c *client) performRequest(req *http.Request) (*http.Response, error) {
-	resp, err := c.httpClient.Do(req)
+// Moderation classifies whether the input text violates OpenAI's content policy.
+func (c *client) Moderation(ctx context.Context, request ModerationRequest) (*ModerationResponse, error) {
+	req, err := c.newRequest(ctx, "Moderation", "POST", "/moderations", request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(ModerationResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// CreateImage creates images given a prompt.
+func (c *client) CreateImage(ctx context.Context, request ImageRequest) (*ImageResponse, error) {
+	req, err := c.newRequest(ctx, "CreateImage", "POST", "/images/generations", request)
+	if err != nil {
+		return nil, err
+	}
+	return c.performImageRequest(req)
+}
+
+// CreateImageEdit creates edited or extended images given an original image and a prompt.
+func (c *client) CreateImageEdit(ctx context.Context, request ImageEditRequest) (*ImageResponse, error) {
+	req, err := c.newMultipartRequest(ctx, "CreateImageEdit", "/images/edits", request, func(writer *multipart.Writer) error {
+		if err := writeFormFile(writer, "image", "image.png", request.Image); err != nil {
+			return err
+		}
+		if request.Mask != nil {
+			if err := writeFormFile(writer, "mask", "mask.png", request.Mask); err != nil {
+				return err
+			}
+		}
+		if err := writeFormField(writer, "prompt", request.Prompt); err != nil {
+			return err
+		}
+		return writeImageFormFields(writer, request.N, request.Size, request.ResponseFormat, request.User)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.performImageRequest(req)
+}
+
+// CreateImageVariation creates variations of a given image.
+func (c *client) CreateImageVariation(ctx context.Context, request ImageVariationRequest) (*ImageResponse, error) {
+	req, err := c.newMultipartRequest(ctx, "CreateImageVariation", "/images/variations", request, func(writer *multipart.Writer) error {
+		if err := writeFormFile(writer, "image", "image.png", request.Image); err != nil {
+			return err
+		}
+		return writeImageFormFields(writer, request.N, request.Size, request.ResponseFormat, request.User)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := checkForSuccess(resp); err != nil {
+	return c.performImageRequest(req)
+}
+
+func writeImageFormFields(writer *multipart.Writer, n *int, size, responseFormat, user string) error {
+	if n != nil {
+		if err := writeFormField(writer, "n", strconv.Itoa(*n)); err != nil {
+			return err
+		}
+	}
+	if err := writeFormField(writer, "size", size); err != nil {
+		return err
+	}
+	if err := writeFormField(writer, "response_format", responseFormat); err != nil {
+		return err
+	}
+	return writeFormField(writer, "user", user)
+}
+
+func (c *client) performImageRequest(req *http.Request) (*ImageResponse, error) {
+	resp, err := c.performRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := new(ImageResponse)
+	if err := getResponseObject(resp, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// CreateTranscription transcribes audio into the input language.
+func (c *client) CreateTranscription(ctx context.Context, request AudioRequest) (*AudioResponse, error) {
+	return c.createAudio(ctx, "CreateTranscription", "/audio/transcriptions", request)
+}
+
+// CreateTranslation translates audio into English.
+func (c *client) CreateTranslation(ctx context.Context, request AudioRequest) (*AudioResponse, error) {
+	if request.Language != "" {
+		return nil, fmt.Errorf("language %q isn't supported by translations, which are always in English", request.Language)
+	}
+	return c.createAudio(ctx, "CreateTranslation", "/audio/translations", request)
+}
+
+func (c *client) createAudio(ctx context.Context, operation, path string, request AudioRequest) (*AudioResponse, error) {
+	if request.Model == "" {
+		request.Model = Whisper1
+	}
+	req, err := c.newMultipartRequest(ctx, operation, path, request, func(writer *multipart.Writer) error {
+		if err := writeFormFile(writer, "file", request.FileName, request.File); err != nil {
+			return err
+		}
+		if err := writeFormField(writer, "model", request.Model); err != nil {
+			return err
+		}
+		if err := writeFormField(writer, "prompt", request.Prompt); err != nil {
+			return err
+		}
+		if err := writeFormField(writer, "language", request.Language); err != nil {
+			return err
+		}
+		if request.Temperature != nil {
+			temperature := strconv.FormatFloat(float64(*request.Temperature), 'f', -1, 32)
+			if err := writeFormField(writer, "temperature", temperature); err != nil {
+				return err
+			}
+		}
+		return writeFormField(writer, "response_format", request.ResponseFormat)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.performRequest(req)
+	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+
+	output := new(AudioResponse)
+	switch request.ResponseFormat {
+	case "", AudioResponseFormatJSON, AudioResponseFormatVerboseJSON:
+		if err := getResponseObject(resp, output); err != nil {
+			return nil, err
+		}
+	default:
+		// text, srt and vtt responses are returned as is
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from body: %w", err)
+		}
+		output.Text = string(data)
+	}
+	return output, nil
+}
+
+func (c *client) performRequest(req *http.Request) (*http.Response, error) {
+	return c.performCall(req, false)
+}
+
+// performStreamRequest performs a streaming request and calls onData with the payload of every
+// data event until the stream is terminated by [DONE]. Failed requests are only retried until the
+// stream starts, never once data has been received.
+func (c *client) performStreamRequest(req *http.Request, onData func(data []byte) error) error {
+	resp, err := c.performCall(req, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reader := newStreamReader(resp)
+	for {
+		data, err := reader.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := onData(data); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForStreamStart blocks until the first bytes of a streamed response body arrived.
+func waitForStreamStart(resp *http.Response) error {
+	reader := bufio.NewReader(resp.Body)
+	if _, err := reader.Peek(1); err != nil {
+		return err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, resp.Body}
+	return nil
 }
 
 // returns an error if this response includes an error.
@@ -450,14 +1044,14 @@ func checkForSuccess(resp *http.Response) error {
 	var result APIErrorResponse
 	if err := json.Unmarshal(data, &result); err != nil {
 		// if we can't decode the json error then create an unexpected error
-		apiError := APIError{
-			StatusCode: resp.StatusCode,
-			Type:       "Unexpected",
-			Message:    string(data),
+		result.Error = APIError{
+			Type:    "Unexpected",
+			Message: string(data),
 		}
-		return apiError
 	}
 	result.Error.StatusCode = resp.StatusCode
+	result.Error.RequestID = resp.Header.Get("X-Request-Id")
+	result.Error.Body = string(data)
 	return result.Error
 }
 
@@ -469,31 +1063,87 @@ func getResponseObject(rsp *http.Response, v interface{}) error {
 	return nil
 }
 
+// jsonBodyReader encodes the body into a bytes.Reader, which lets http.NewRequest set up GetBody
+// so that the body can be replayed when the request is retried.
 func jsonBodyReader(body interface{}) (io.Reader, error) {
 	if body == nil {
-		return bytes.NewBuffer(nil), nil
+		return bytes.NewReader(nil), nil
 	}
 	raw, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed encoding json: %w", err)
 	}
-	return bytes.NewBuffer(raw), nil
+	return bytes.NewReader(raw), nil
 }
 
-func (c *client) newRequest(ctx context.Context, method, path string, payload interface{}) (*http.Request, error) {
+// newRequest creates a request with a json encoded payload. The operation and payload are recorded in the
+// context of the request for the middleware of the client.
+func (c *client) newRequest(ctx context.Context, operation, method, path string, payload interface{}) (*http.Request, error) {
 	bodyReader, err := jsonBodyReader(payload)
 	if err != nil {
 		return nil, err
 	}
 	url := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	req, err := http.NewRequestWithContext(withCall(withTokenEstimate(ctx, payload), operation, payload), method, url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
+	c.setRequestHeaders(req, "application/json")
+	return req, nil
+}
+
+// newMultipartRequest creates a POST request with a multipart/form-data body written by build.
+func (c *client) newMultipartRequest(
+	ctx context.Context,
+	operation, path string,
+	payload interface{},
+	build func(*multipart.Writer) error,
+) (*http.Request, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := build(writer); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	// a bytes.Reader body can be replayed when the request is retried
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(withCall(ctx, operation, payload), "POST", url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	c.setRequestHeaders(req, writer.FormDataContentType())
+	return req, nil
+}
+
+func (c *client) setRequestHeaders(req *http.Request, contentType string) {
 	if len(c.idOrg) > 0 {
 		req.Header.Set("OpenAI-Organization", c.idOrg)
 	}
-	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("Content-type", contentType)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	return req, nil
+}
+
+func writeFormFile(writer *multipart.Writer, fieldname, filename string, r io.Reader) error {
+	if r == nil {
+		return fmt.Errorf("missing %s", fieldname)
+	}
+	part, err := writer.CreateFormFile(fieldname, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return fmt.Errorf("failed writing %s: %w", fieldname, err)
+	}
+	return nil
+}
+
+// writeFormField writes a form field to the multipart writer, empty values are skipped.
+func writeFormField(writer *multipart.Writer, fieldname, value string) error {
+	if value == "" {
+		return nil
+	}
+	return writer.WriteField(fieldname, value)
 }
@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 
 	fakes "github.com/alexandrubordei/go-gpt3/go-gpt3fakes"
 	"github.com/stretchr/testify/assert"
@@ -55,12 +59,49 @@ func TestRequestCreationFails(t *testing.T) {
 			},
 			"Get \"https://api.openai.com/v1/engines/davinci\": request error",
 		},
+		{
+			"ListModels",
+			func() (interface{}, error) {
+				return client.ListModels(ctx)
+			},
+			"Get \"https://api.openai.com/v1/models\": request error",
+		},
+		{
+			"GetModel",
+			func() (interface{}, error) {
+				return client.GetModel(ctx, TextDavinci001Engine)
+			},
+			"Get \"https://api.openai.com/v1/models/text-davinci-001\": request error",
+		},
+		{
+			"DeleteModel",
+			func() (interface{}, error) {
+				return client.DeleteModel(ctx, "curie:ft-acme-2021-03-03-21-44-20")
+			},
+			"Delete \"https://api.openai.com/v1/models/curie:ft-acme-2021-03-03-21-44-20\": request error",
+		},
 		{
 			"Completion",
 			func() (interface{}, error) {
 				return client.Completion(ctx, CompletionRequest{})
 			},
 			"Post \"https://api.openai.com/v1/engines/davinci/completions\": request error",
+		}, {
+			"CompletionWithModel",
+			func() (interface{}, error) {
+				return client.Completion(ctx, CompletionRequest{Model: TextDavinci001Engine})
+			},
+			"Post \"https://api.openai.com/v1/completions\": request error",
+		}, {
+			"CompletionStreamWithModel",
+			func() (interface{}, error) {
+				var rsp *CompletionResponse
+				onData := func(data *CompletionResponse) {
+					rsp = data
+				}
+				return rsp, client.CompletionStream(ctx, CompletionRequest{Model: TextDavinci001Engine}, onData)
+			},
+			"Post \"https://api.openai.com/v1/completions\": request error",
 		}, {
 			"CompletionStream",
 			func() (interface{}, error) {
@@ -87,12 +128,134 @@ func TestRequestCreationFails(t *testing.T) {
 				return rsp, client.CompletionStreamWithEngine(ctx, AdaEngine, CompletionRequest{}, onData)
 			},
 			"Post \"https://api.openai.com/v1/engines/ada/completions\": request error",
+		}, {
+			"ChatCompletion",
+			func() (interface{}, error) {
+				return client.ChatCompletion(ctx, ChatCompletionRequest{})
+			},
+			"Post \"https://api.openai.com/v1/chat/completions\": request error",
+		}, {
+			"ChatCompletionStream",
+			func() (interface{}, error) {
+				var rsp *ChatCompletionStreamResponse
+				onData := func(data *ChatCompletionStreamResponse) {
+					rsp = data
+				}
+				return rsp, client.ChatCompletionStream(ctx, ChatCompletionRequest{}, onData)
+			},
+			"Post \"https://api.openai.com/v1/chat/completions\": request error",
 		}, {
 			"Edits",
 			func() (interface{}, error) {
 				return client.Edits(ctx, EditsRequest{})
 			},
 			"Post \"https://api.openai.com/v1/edits\": request error",
+		}, {
+			"ListFiles",
+			func() (interface{}, error) {
+				return client.ListFiles(ctx, "")
+			},
+			"Get \"https://api.openai.com/v1/files\": request error",
+		}, {
+			"ListFilesWithPurpose",
+			func() (interface{}, error) {
+				return client.ListFiles(ctx, FineTunePurpose)
+			},
+			"Get \"https://api.openai.com/v1/files?purpose=fine-tune\": request error",
+		}, {
+			"GetFile",
+			func() (interface{}, error) {
+				return client.GetFile(ctx, "file-123")
+			},
+			"Get \"https://api.openai.com/v1/files/file-123\": request error",
+		}, {
+			"DownloadFileContent",
+			func() (interface{}, error) {
+				return client.DownloadFileContent(ctx, "file-123")
+			},
+			"Get \"https://api.openai.com/v1/files/file-123/content\": request error",
+		}, {
+			"ListFineTunes",
+			func() (interface{}, error) {
+				return client.ListFineTunes(ctx)
+			},
+			"Get \"https://api.openai.com/v1/fine-tunes\": request error",
+		}, {
+			"CancelFineTune",
+			func() (interface{}, error) {
+				return client.CancelFineTune(ctx, "ft-123")
+			},
+			"Post \"https://api.openai.com/v1/fine-tunes/ft-123/cancel\": request error",
+		}, {
+			"WaitForFineTune",
+			func() (interface{}, error) {
+				return client.WaitForFineTune(ctx, "ft-123", WaitForFineTuneOptions{})
+			},
+			"Get \"https://api.openai.com/v1/fine-tunes/ft-123\": request error",
+		}, {
+			"ListFineTuneEvents",
+			func() (interface{}, error) {
+				return client.ListFineTuneEvents(ctx, "ft-123")
+			},
+			"Get \"https://api.openai.com/v1/fine-tunes/ft-123/events\": request error",
+		}, {
+			"StreamFineTuneEvents",
+			func() (interface{}, error) {
+				var rsp *Event
+				onEvent := func(event *Event) {
+					rsp = event
+				}
+				return rsp, client.StreamFineTuneEvents(ctx, "ft-123", onEvent)
+			},
+			"Get \"https://api.openai.com/v1/fine-tunes/ft-123/events?stream=true\": request error",
+		}, {
+			"Moderation",
+			func() (interface{}, error) {
+				return client.Moderation(ctx, ModerationRequest{})
+			},
+			"Post \"https://api.openai.com/v1/moderations\": request error",
+		}, {
+			"CreateImage",
+			func() (interface{}, error) {
+				return client.CreateImage(ctx, ImageRequest{})
+			},
+			"Post \"https://api.openai.com/v1/images/generations\": request error",
+		}, {
+			"CreateImageEdit",
+			func() (interface{}, error) {
+				return client.CreateImageEdit(ctx, ImageEditRequest{Image: bytes.NewBufferString("png")})
+			},
+			"Post \"https://api.openai.com/v1/images/edits\": request error",
+		}, {
+			"CreateImageVariation",
+			func() (interface{}, error) {
+				return client.CreateImageVariation(ctx, ImageVariationRequest{Image: bytes.NewBufferString("png")})
+			},
+			"Post \"https://api.openai.com/v1/images/variations\": request error",
+		}, {
+			"CreateTranscription",
+			func() (interface{}, error) {
+				return client.CreateTranscription(ctx, AudioRequest{File: bytes.NewBufferString("audio"), FileName: "call.mp3"})
+			},
+			"Post \"https://api.openai.com/v1/audio/transcriptions\": request error",
+		}, {
+			"CreateTranslation",
+			func() (interface{}, error) {
+				return client.CreateTranslation(ctx, AudioRequest{File: bytes.NewBufferString("audio"), FileName: "call.mp3"})
+			},
+			"Post \"https://api.openai.com/v1/audio/translations\": request error",
+		}, {
+			"Answers",
+			func() (interface{}, error) {
+				return client.Answers(ctx, AnswersRequest{})
+			},
+			"Post \"https://api.openai.com/v1/answers\": request error",
+		}, {
+			"Classifications",
+			func() (interface{}, error) {
+				return client.Classifications(ctx, ClassificationsRequest{})
+			},
+			"Post \"https://api.openai.com/v1/classifications\": request error",
 		}, {
 			"Search",
 			func() (interface{}, error) {
@@ -117,6 +280,10 @@ func TestRequestCreationFails(t *testing.T) {
 	}
 }
 
+func stringPtr(s string) *string {
+	return &s
+}
+
 type errReader int
 
 func (errReader) Read(p []byte) (n int, err error) {
@@ -163,6 +330,76 @@ func TestResponses(t *testing.T) {
 				Ready:  true,
 			},
 		},
+		{
+			"ListModels",
+			func() (interface{}, error) {
+				return client.ListModels(ctx)
+			},
+			&ModelsResponse{
+				Data: []Model{
+					{
+						ID:      "curie:ft-acme-2021-03-03-21-44-20",
+						Object:  "model",
+						Created: 123456789,
+						OwnedBy: "acme",
+						Permission: []ModelPermission{
+							{
+								ID:            "modelperm-123",
+								Object:        "model_permission",
+								AllowSampling: true,
+								AllowView:     true,
+								Organization:  "*",
+							},
+						},
+						Root:   "curie",
+						Parent: stringPtr("curie"),
+					},
+				},
+				Object: "list",
+			},
+		},
+		{
+			"GetModel",
+			func() (interface{}, error) {
+				return client.GetModel(ctx, TextDavinci001Engine)
+			},
+			&Model{
+				ID:      TextDavinci001Engine,
+				Object:  "model",
+				Created: 123456789,
+				OwnedBy: "openai",
+				Root:    TextDavinci001Engine,
+			},
+		},
+		{
+			"DeleteModel",
+			func() (interface{}, error) {
+				return client.DeleteModel(ctx, "curie:ft-acme-2021-03-03-21-44-20")
+			},
+			&ModelDeleteResponse{
+				ID:      "curie:ft-acme-2021-03-03-21-44-20",
+				Object:  "model",
+				Deleted: true,
+			},
+		},
+		{
+			"CompletionWithModel",
+			func() (interface{}, error) {
+				return client.Completion(ctx, CompletionRequest{Model: TextDavinci001Engine})
+			},
+			&CompletionResponse{
+				ID:      "123",
+				Object:  "text_completion",
+				Created: 123456789,
+				Model:   TextDavinci001Engine,
+				Choices: []CompletionResponseChoice{
+					{
+						Text:         "output",
+						FinishReason: "stop",
+					},
+				},
+			},
+		},
 		{
 			"Completion",
 			func() (interface{}, error) {
@@ -217,6 +454,268 @@ func TestResponses(t *testing.T) {
 				return rsp, client.CompletionStreamWithEngine(ctx, AdaEngine, CompletionRequest{}, onData)
 			},
 			nil, // streaming responses are tested separately
+		}, {
+			"ChatCompletion",
+			func() (interface{}, error) {
+				return client.ChatCompletion(ctx, ChatCompletionRequest{})
+			},
+			&ChatCompletionResponse{
+				ID:      "123",
+				Object:  "chat.completion",
+				Created: 123456789,
+				Model:   GPT3Dot5Turbo,
+				Choices: []ChatCompletionResponseChoice{
+					{
+						Message: ChatCompletionResponseMessage{
+							Role:    ChatRoleAssistant,
+							Content: "output",
+						},
+						FinishReason: "stop",
+					},
+				},
+				Usage: ChatCompletionsResponseUsage{
+					PromptTokens:     1,
+					CompletionTokens: 2,
+					TotalTokens:      3,
+				},
+			},
+		}, {
+			"ChatCompletionStream",
+			func() (interface{}, error) {
+				var rsp *ChatCompletionStreamResponse
+				onData := func(data *ChatCompletionStreamResponse) {
+					rsp = data
+				}
+				return rsp, client.ChatCompletionStream(ctx, ChatCompletionRequest{}, onData)
+			},
+			nil, // streaming responses are tested separately
+		}, {
+			"ListFiles",
+			func() (interface{}, error) {
+				return client.ListFiles(ctx, FineTunePurpose)
+			},
+			&FilesResponse{
+				Data: []File{
+					{
+						ID:        "file-123",
+						Object:    "file",
+						Bytes:     140,
+						CreatedAt: 123456789,
+						Filename:  "train.jsonl",
+						Purpose:   FineTunePurpose,
+					},
+				},
+				Object: "list",
+			},
+		}, {
+			"GetFile",
+			func() (interface{}, error) {
+				return client.GetFile(ctx, "file-123")
+			},
+			&File{
+				ID:        "file-123",
+				Object:    "file",
+				Bytes:     140,
+				CreatedAt: 123456789,
+				Filename:  "train.jsonl",
+				Purpose:   FineTunePurpose,
+			},
+		}, {
+			"ListFineTunes",
+			func() (interface{}, error) {
+				return client.ListFineTunes(ctx)
+			},
+			&FineTunesResponse{
+				Data: []FineTuneResponse{
+					{
+						ID:     "ft-123",
+						Object: "fine-tune",
+						Model:  CurieEngine,
+						Status: "pending",
+					},
+				},
+				Object: "list",
+			},
+		}, {
+			"CancelFineTune",
+			func() (interface{}, error) {
+				return client.CancelFineTune(ctx, "ft-123")
+			},
+			&FineTuneResponse{
+				ID:     "ft-123",
+				Object: "fine-tune",
+				Model:  CurieEngine,
+				Status: "cancelled",
+			},
+		}, {
+			"WaitForFineTune",
+			func() (interface{}, error) {
+				return client.WaitForFineTune(ctx, "ft-123", WaitForFineTuneOptions{})
+			},
+			&FineTuneResponse{
+				ID:             "ft-123",
+				Object:         "fine-tune",
+				Model:          CurieEngine,
+				Status:         FineTuneStatusSucceeded,
+				FineTunedModel: stringPtr("curie:ft-acme-2021-03-03-21-44-20"),
+			},
+		}, {
+			"ListFineTuneEvents",
+			func() (interface{}, error) {
+				return client.ListFineTuneEvents(ctx, "ft-123")
+			},
+			&FineTuneEventsResponse{
+				Data: []Event{
+					{
+						Object:    "fine-tune-event",
+						CreatedAt: 123456789,
+						Level:     "info",
+						Message:   "Job enqueued. Waiting for jobs ahead to complete. Queue number: 0.",
+					},
+				},
+				Object: "list",
+			},
+		}, {
+			"StreamFineTuneEvents",
+			func() (interface{}, error) {
+				var rsp *Event
+				onEvent := func(event *Event) {
+					rsp = event
+				}
+				return rsp, client.StreamFineTuneEvents(ctx, "ft-123", onEvent)
+			},
+			nil, // streaming responses are tested separately
+		}, {
+			"Moderation",
+			func() (interface{}, error) {
+				return client.Moderation(ctx, ModerationRequest{Input: []string{"I want to kill them."}})
+			},
+			&ModerationResponse{
+				ID:    "modr-123",
+				Model: "text-moderation-001",
+				Results: []ModerationResult{
+					{
+						Flagged: true,
+						Categories: ModerationCategories{
+							Violence: true,
+						},
+						CategoryScores: ModerationCategoryScores{
+							Hate:     0.2,
+							Violence: 0.9,
+						},
+					},
+				},
+			},
+		}, {
+			"CreateImage",
+			func() (interface{}, error) {
+				return client.CreateImage(ctx, ImageRequest{Prompt: "a white siamese cat"})
+			},
+			&ImageResponse{
+				Created: 123456789,
+				Data: []ImageResponseData{
+					{URL: "https://example.com/image.png"},
+				},
+			},
+		}, {
+			"CreateImageEdit",
+			func() (interface{}, error) {
+				return client.CreateImageEdit(ctx, ImageEditRequest{Image: bytes.NewBufferString("png")})
+			},
+			&ImageResponse{
+				Created: 123456789,
+				Data: []ImageResponseData{
+					{B64JSON: "cG5n"},
+				},
+			},
+		}, {
+			"CreateImageVariation",
+			func() (interface{}, error) {
+				return client.CreateImageVariation(ctx, ImageVariationRequest{Image: bytes.NewBufferString("png")})
+			},
+			&ImageResponse{
+				Created: 123456789,
+				Data: []ImageResponseData{
+					{URL: "https://example.com/image.png"},
+				},
+			},
+		}, {
+			"CreateTranscription",
+			func() (interface{}, error) {
+				return client.CreateTranscription(ctx, AudioRequest{File: bytes.NewBufferString("audio"), FileName: "call.mp3"})
+			},
+			&AudioResponse{
+				Text: "Hello, how can I help you?",
+			},
+		}, {
+			"CreateTranslation",
+			func() (interface{}, error) {
+				return client.CreateTranslation(ctx, AudioRequest{
+					File:           bytes.NewBufferString("audio"),
+					FileName:       "call.mp3",
+					ResponseFormat: AudioResponseFormatVerboseJSON,
+				})
+			},
+			&AudioResponse{
+				Task:     "translate",
+				Language: "english",
+				Duration: 2.5,
+				Text:     "Hello, how can I help you?",
+				Segments: []AudioSegment{
+					{
+						ID:           0,
+						Start:        0,
+						End:          2.5,
+						Text:         "Hello, how can I help you?",
+						Tokens:       []int{50364, 2425},
+						AvgLogprob:   -0.3,
+						NoSpeechProb: 0.01,
+					},
+				},
+			},
+		}, {
+			"Answers",
+			func() (interface{}, error) {
+				return client.Answers(ctx, AnswersRequest{
+					Model:           CurieEngine,
+					Question:        "which puppy is happy?",
+					Examples:        [][]string{{"What is human life expectancy in the United States?", "78 years."}},
+					ExamplesContext: "In 2017, U.S. life expectancy was 78.6 years.",
+					File:            "file-123",
+					ReturnPrompt:    true,
+				})
+			},
+			&AnswersResponse{
+				Object:      "answer",
+				Model:       CurieEngine,
+				SearchModel: AdaEngine,
+				Completion:  "cmpl-123",
+				Answers:     []string{"puppy A."},
+				SelectedDocuments: []AnswersResponseDocument{
+					{Document: 0, Text: "puppy A is happy", Metadata: json.RawMessage(`{"source":"faq","page":3}`)},
+				},
+				Prompt: "Please answer the question according to the above context.",
+			},
+		}, {
+			"Classifications",
+			func() (interface{}, error) {
+				return client.Classifications(ctx, ClassificationsRequest{
+					Model:  CurieEngine,
+					Query:  "It is a raining day :(",
+					File:   "file-123",
+					Labels: []string{"Positive", "Negative", "Neutral"},
+				})
+			},
+			&ClassificationsResponse{
+				Object:      "classification",
+				Model:       CurieEngine,
+				SearchModel: AdaEngine,
+				Completion:  "cmpl-123",
+				Label:       "Negative",
+				SelectedExamples: []ClassificationsResponseExample{
+					{Document: 1, Label: "Negative", Text: "The weather is so gloomy", Metadata: json.RawMessage(`"weather"`)},
+				},
+			},
 		}, {
 			"Search",
 			func() (interface{}, error) {
@@ -287,6 +786,7 @@ func TestResponses(t *testing.T) {
 
 					mockResponse = &http.Response{
 						StatusCode: code,
+						Header:     http.Header{"X-Request-Id": []string{"req-123"}},
 						Body:       ioutil.NopCloser(bytes.NewBuffer(data)),
 					}
 
@@ -295,6 +795,8 @@ func TestResponses(t *testing.T) {
 					assert.Nil(t, rsp)
 					assert.EqualError(t, err, fmt.Sprintf("[%d:test_type] test message", code))
 					apiErrorResponse.Error.StatusCode = code
+					apiErrorResponse.Error.RequestID = "req-123"
+					apiErrorResponse.Error.Body = string(data)
 					assert.Equal(t, apiErrorResponse.Error, err)
 				}
 			})
@@ -352,4 +854,528 @@ func testEmbeddings(t *testing.T) {
 
 }
 
+func TestAPIErrors(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	type testCase struct {
+		name      string
+		code      int
+		body      string
+		sentinels []error
+		retryable bool
+	}
+
+	allSentinels := []error{
+		ErrRateLimited, ErrUnauthorized, ErrQuotaExceeded, ErrContextLengthExceeded, ErrModelNotFound, ErrServerError,
+	}
+	testCases := []testCase{
+		{
+			"rate limited",
+			429,
+			`{"error":{"type":"requests","message":"Rate limit reached for requests"}}`,
+			[]error{ErrRateLimited},
+			true,
+		},
+		{
+			"quota exceeded",
+			429,
+			`{"error":{"type":"insufficient_quota","code":"insufficient_quota","message":"You exceeded your current quota"}}`,
+			[]error{ErrQuotaExceeded},
+			false,
+		},
+		{
+			"unauthorized",
+			401,
+			`{"error":{"type":"invalid_request_error","code":"invalid_api_key","message":"Incorrect API key provided"}}`,
+			[]error{ErrUnauthorized},
+			false,
+		},
+		{
+			"context length exceeded",
+			400,
+			`{"error":{"type":"invalid_request_error","param":"messages","code":"context_length_exceeded","message":"This model's maximum context length is 4097 tokens."}}`,
+			[]error{ErrContextLengthExceeded},
+			false,
+		},
+		{
+			"model not found",
+			404,
+			`{"error":{"type":"invalid_request_error","param":"model","code":"model_not_found","message":"The model 'gpt-5' does not exist"}}`,
+			[]error{ErrModelNotFound},
+			false,
+		},
+		{
+			"server error",
+			503,
+			`{"error":{"type":"server_error","message":"The server is overloaded"}}`,
+			[]error{ErrServerError},
+			true,
+		},
+		{
+			"unexpected error",
+			502,
+			`bad gateway`,
+			[]error{ErrServerError},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt.RoundTripReturns(&http.Response{
+				StatusCode: tc.code,
+				Header:     http.Header{"X-Request-Id": []string{"req-123"}},
+				Body:       ioutil.NopCloser(bytes.NewBufferString(tc.body)),
+			}, nil)
+
+			_, err := client.ChatCompletion(ctx, ChatCompletionRequest{})
+			for _, sentinel := range allSentinels {
+				expected := false
+				for _, s := range tc.sentinels {
+					expected = expected || s == sentinel
+				}
+				assert.Equal(t, expected, errors.Is(err, sentinel), sentinel.Error())
+			}
+			assert.Equal(t, tc.retryable, IsRetryable(err))
+
+			var apiErr APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tc.code, apiErr.StatusCode)
+			assert.Equal(t, "req-123", apiErr.RequestID)
+			assert.Equal(t, tc.body, apiErr.Body)
+		})
+	}
+
+	t.Run("transport errors", func(t *testing.T) {
+		rt.RoundTripReturns(nil, syscall.ECONNRESET)
+		_, err := client.ChatCompletion(ctx, ChatCompletionRequest{})
+		var transportErr *TransportError
+		assert.True(t, errors.As(err, &transportErr))
+		assert.True(t, errors.Is(err, syscall.ECONNRESET))
+		assert.True(t, IsRetryable(err))
+
+		rt.RoundTripReturns(nil, errors.New("request error"))
+		_, err = client.ChatCompletion(ctx, ChatCompletionRequest{})
+		assert.True(t, errors.As(err, &transportErr))
+		assert.False(t, IsRetryable(err))
+
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			return nil, req.Context().Err()
+		}
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = client.ChatCompletion(cancelled, ChatCompletionRequest{})
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.False(t, IsRetryable(err))
+	})
+}
+
+func TestCompletionWithModel(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"id":"123"}`)),
+	}, nil)
+
+	_, err := client.Completion(ctx, CompletionRequest{Model: TextDavinci001Engine})
+	assert.NoError(t, err)
+
+	req := rt.RoundTripArgsForCall(0)
+	assert.Equal(t, "/v1/completions", req.URL.Path)
+	sent := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&sent))
+	assert.Equal(t, TextDavinci001Engine, sent["model"])
+}
+
+func TestCompletionRequestParameters(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return jsonResponse(t, &CompletionResponse{}), nil
+	}
+
+	sentParameters := func(call int) map[string]interface{} {
+		sent := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(rt.RoundTripArgsForCall(call).Body).Decode(&sent))
+		return sent
+	}
+
+	_, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.NoError(t, err)
+	sent := sentParameters(0)
+	for _, unset := range []string{"suffix", "n", "logprobs", "echo", "presence_penalty", "frequency_penalty", "best_of", "logit_bias", "user"} {
+		assert.NotContains(t, sent, unset)
+	}
+
+	_, err = client.Completion(ctx, CompletionRequest{
+		Prompt:           PromptString("hello"),
+		Suffix:           stringPtr("world"),
+		N:                IntPtr(2),
+		LogProbs:         IntPtr(0),
+		Echo:             true,
+		PresencePenalty:  0.5,
+		FrequencyPenalty: -0.5,
+		BestOf:           IntPtr(4),
+		LogitBias:        LogitBias{"50256": -100},
+		User:             "user-123",
+	})
+	assert.NoError(t, err)
+	sent = sentParameters(1)
+	assert.Equal(t, "world", sent["suffix"])
+	assert.Equal(t, 2.0, sent["n"])
+	assert.Equal(t, 0.0, sent["logprobs"])
+	assert.Equal(t, true, sent["echo"])
+	assert.Equal(t, 0.5, sent["presence_penalty"])
+	assert.Equal(t, -0.5, sent["frequency_penalty"])
+	assert.Equal(t, 4.0, sent["best_of"])
+	assert.Equal(t, map[string]interface{}{"50256": -100.0}, sent["logit_bias"])
+	assert.Equal(t, "user-123", sent["user"])
+}
+
+func TestDownloadFileContent(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	content := "{\"prompt\": \"a\", \"completion\": \"b\"}\n"
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(content)),
+	}, nil)
+
+	rsp, err := client.DownloadFileContent(ctx, "file-123")
+	assert.NoError(t, err)
+	defer rsp.Close()
+	data, err := ioutil.ReadAll(rsp)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 404,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"error":{"type":"invalid_request_error","message":"No such File object: file-123"}}`)),
+	}, nil)
+
+	rsp, err = client.DownloadFileContent(ctx, "file-123")
+	assert.Nil(t, rsp)
+	assert.EqualError(t, err, "[404:invalid_request_error] No such File object: file-123")
+}
+
+func TestStreamFineTuneEvents(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	body := `data: {"object":"fine-tune-event","created_at":1,"level":"info","message":"Job enqueued"}
+
+data: {"object":"fine-tune-event","created_at":2,"level":"info","message":"Job started"}
+
+data: [DONE]
+
+`
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}, nil)
+
+	var events []*Event
+	err := client.StreamFineTuneEvents(ctx, "ft-123", func(event *Event) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Event{
+		{Object: "fine-tune-event", CreatedAt: 1, Level: "info", Message: "Job enqueued"},
+		{Object: "fine-tune-event", CreatedAt: 2, Level: "info", Message: "Job started"},
+	}, events)
+}
+
+func fineTuneResponse(t *testing.T, status string, events ...string) *http.Response {
+	fineTune := FineTuneResponse{ID: "ft-123", Status: status}
+	for i, message := range events {
+		fineTune.Events = append(fineTune.Events, Event{CreatedAt: i, Message: message})
+	}
+	data, err := json.Marshal(fineTune)
+	assert.NoError(t, err)
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBuffer(data)),
+	}
+}
+
+func TestWaitForFineTune(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports events once and fails", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturnsOnCall(0, fineTuneResponse(t, FineTuneStatusPending, "created"), nil)
+		rt.RoundTripReturnsOnCall(1, fineTuneResponse(t, FineTuneStatusPending, "created"), nil)
+		rt.RoundTripReturnsOnCall(2, fineTuneResponse(t, FineTuneStatusRunning, "created", "started"), nil)
+		rt.RoundTripReturnsOnCall(3, fineTuneResponse(t, FineTuneStatusFailed, "created", "started", "failed"), nil)
+
+		var events []string
+		var statuses []string
+		rsp, err := client.WaitForFineTune(ctx, "ft-123", WaitForFineTuneOptions{
+			PollInterval:    time.Millisecond,
+			BackoffFactor:   2,
+			MaxPollInterval: 4 * time.Millisecond,
+			OnEvent: func(event Event) {
+				events = append(events, event.Message)
+			},
+			OnStatus: func(fineTune *FineTuneResponse) {
+				statuses = append(statuses, fineTune.Status)
+			},
+		})
+		assert.Equal(t, 4, rt.RoundTripCallCount())
+		assert.Equal(t, []string{"created", "started", "failed"}, events)
+		assert.Equal(t, []string{FineTuneStatusPending, FineTuneStatusRunning, FineTuneStatusFailed}, statuses)
+		assert.Equal(t, FineTuneStatusFailed, rsp.Status)
+
+		var fineTuneErr *FineTuneError
+		assert.True(t, errors.As(err, &fineTuneErr))
+		assert.Equal(t, FineTuneStatusFailed, fineTuneErr.Status)
+		assert.EqualError(t, err, "fine-tune ft-123 failed")
+	})
+
+	t.Run("honours context cancellation", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+			return fineTuneResponse(t, FineTuneStatusRunning), nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		rsp, err := client.WaitForFineTune(ctx, "ft-123", WaitForFineTuneOptions{
+			PollInterval: time.Hour,
+		})
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, FineTuneStatusRunning, rsp.Status)
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+	})
+}
+
+func jsonResponse(t *testing.T, v interface{}) *http.Response {
+	data, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBuffer(data)),
+	}
+}
+
+func TestModerationGuard(t *testing.T) {
+	ctx := context.Background()
+	flagged := &ModerationResponse{
+		Results: []ModerationResult{
+			{Flagged: false},
+			{Flagged: true, Categories: ModerationCategories{Hate: true, Violence: true}},
+		},
+	}
+	clean := &ModerationResponse{Results: []ModerationResult{{Flagged: false}}}
+	completion := &CompletionResponse{
+		ID:      "123",
+		Choices: []CompletionResponseChoice{{Text: "first"}, {Text: "second"}},
+	}
+
+	t.Run("blocks flagged prompts", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithModerationGuard(ModerationGuardOptions{}))
+		rt.RoundTripReturnsOnCall(0, jsonResponse(t, flagged), nil)
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("a prompt")})
+		assert.Nil(t, rsp)
+		assert.EqualError(t, err, "prompt blocked by moderation: hate, violence")
+		var blocked *ModerationBlockedError
+		assert.True(t, errors.As(err, &blocked))
+		assert.Equal(t, []string{"hate", "violence"}, blocked.Categories)
+		assert.False(t, blocked.Output)
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+		assert.Equal(t, "/v1/moderations", rt.RoundTripArgsForCall(0).URL.Path)
+
+		rt.RoundTripReturnsOnCall(1, jsonResponse(t, flagged), nil)
+		err = client.CompletionStream(ctx, CompletionRequest{Prompt: PromptString("a prompt")}, func(*CompletionResponse) {})
+		assert.True(t, errors.As(err, &blocked))
+		assert.Equal(t, 2, rt.RoundTripCallCount())
+	})
+
+	t.Run("screens output when enabled", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithModerationGuard(ModerationGuardOptions{
+			Model:        TextModerationStable,
+			ScreenOutput: true,
+		}))
+		rt.RoundTripReturnsOnCall(0, jsonResponse(t, clean), nil)
+		rt.RoundTripReturnsOnCall(1, jsonResponse(t, completion), nil)
+		rt.RoundTripReturnsOnCall(2, jsonResponse(t, flagged), nil)
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("a prompt")})
+		assert.Nil(t, rsp)
+		assert.EqualError(t, err, "completion blocked by moderation: hate, violence")
+		assert.Equal(t, 3, rt.RoundTripCallCount())
+
+		sent := ModerationRequest{}
+		assert.NoError(t, json.NewDecoder(rt.RoundTripArgsForCall(2).Body).Decode(&sent))
+		assert.Equal(t, ModerationRequest{Input: []string{"first", "second"}, Model: TextModerationStable}, sent)
+	})
+
+	t.Run("passes clean completions through", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithModerationGuard(ModerationGuardOptions{
+			ScreenOutput: true,
+		}))
+		rt.RoundTripReturnsOnCall(0, jsonResponse(t, clean), nil)
+		rt.RoundTripReturnsOnCall(1, jsonResponse(t, completion), nil)
+		rt.RoundTripReturnsOnCall(2, jsonResponse(t, clean), nil)
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("a prompt")})
+		assert.NoError(t, err)
+		assert.Equal(t, completion, rsp)
+	})
+}
+
+func TestCreateImageEdit(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithOrg("org-123"))
+	rt.RoundTripReturns(jsonResponse(t, &ImageResponse{}), nil)
+
+	_, err := client.CreateImageEdit(ctx, ImageEditRequest{
+		Image:          bytes.NewBufferString("image data"),
+		Mask:           bytes.NewBufferString("mask data"),
+		Prompt:         "a sunlit indoor lounge area with a pool",
+		N:              IntPtr(2),
+		Size:           ImageSize512x512,
+		ResponseFormat: ImageResponseFormatB64JSON,
+	})
+	assert.NoError(t, err)
+
+	req := rt.RoundTripArgsForCall(0)
+	assert.Equal(t, "org-123", req.Header.Get("OpenAI-Organization"))
+	assert.NoError(t, req.ParseMultipartForm(1<<20))
+	assert.Equal(t, "a sunlit indoor lounge area with a pool", req.FormValue("prompt"))
+	assert.Equal(t, "2", req.FormValue("n"))
+	assert.Equal(t, ImageSize512x512, req.FormValue("size"))
+	assert.Equal(t, ImageResponseFormatB64JSON, req.FormValue("response_format"))
+	assert.Empty(t, req.MultipartForm.Value["user"])
+	for field, content := range map[string]string{"image": "image data", "mask": "mask data"} {
+		file, _, err := req.FormFile(field)
+		assert.NoError(t, err)
+		data, err := ioutil.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+
+	_, err = client.CreateImageEdit(ctx, ImageEditRequest{Prompt: "no image"})
+	assert.EqualError(t, err, "missing image")
+}
+
+func TestImageResponseDataWriteToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpt3")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "image.png")
+	assert.NoError(t, ImageResponseData{B64JSON: "aW1hZ2UgZGF0YQ=="}.WriteToFile(filename))
+	data, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "image data", string(data))
+
+	assert.EqualError(t, ImageResponseData{URL: "https://example.com/image.png"}.WriteToFile(filename), "image has no b64_json data")
+}
+
+func TestCreateTranscription(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	srt := "1\n00:00:00,000 --> 00:00:02,500\nHello, how can I help you?\n"
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(srt)),
+	}, nil)
+
+	rsp, err := client.CreateTranscription(ctx, AudioRequest{
+		File:           bytes.NewBufferString("audio data"),
+		FileName:       "call.mp3",
+		Prompt:         "Customer support call",
+		Language:       "en",
+		Temperature:    Float32Ptr(0.2),
+		ResponseFormat: AudioResponseFormatSRT,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &AudioResponse{Text: srt}, rsp)
+
+	req := rt.RoundTripArgsForCall(0)
+	assert.NoError(t, req.ParseMultipartForm(1<<20))
+	assert.Equal(t, Whisper1, req.FormValue("model"))
+	assert.Equal(t, "Customer support call", req.FormValue("prompt"))
+	assert.Equal(t, "en", req.FormValue("language"))
+	assert.Equal(t, "0.2", req.FormValue("temperature"))
+	assert.Equal(t, AudioResponseFormatSRT, req.FormValue("response_format"))
+	file, header, err := req.FormFile("file")
+	assert.NoError(t, err)
+	assert.Equal(t, "call.mp3", header.Filename)
+	data, err := ioutil.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "audio data", string(data))
+}
+
+func TestCreateTranslationRejectsLanguage(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	_, err := client.CreateTranslation(ctx, AudioRequest{File: bytes.NewBufferString("audio data"), FileName: "call.mp3", Language: "fr"})
+	assert.EqualError(t, err, `language "fr" isn't supported by translations, which are always in English`)
+	assert.Equal(t, 0, rt.RoundTripCallCount())
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient))
+
+	body := `data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant"}}]}
+
+data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hello"}}]}
+
+data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	rt.RoundTripReturns(&http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}, nil)
+
+	var content string
+	var chunks []*ChatCompletionStreamResponse
+	err := client.ChatCompletionStream(ctx, ChatCompletionRequest{
+		Messages: []ChatCompletionRequestMessage{
+			{Role: ChatRoleUser, Content: "Say hello"},
+		},
+	}, func(rsp *ChatCompletionStreamResponse) {
+		chunks = append(chunks, rsp)
+		content += rsp.Choices[0].Delta.Content
+	})
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, ChatRoleAssistant, chunks[0].Choices[0].Delta.Role)
+	assert.Equal(t, "stop", chunks[2].Choices[0].FinishReason)
+	assert.Equal(t, "Hello world", content)
+
+	req := rt.RoundTripArgsForCall(0)
+	sent := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&sent))
+	assert.Equal(t, DefaultChatModel, sent["model"])
+	assert.Equal(t, true, sent["stream"])
+}
+
 // TODO: add streaming response tests
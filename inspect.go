@@ -0,0 +1,133 @@
+package gpt3
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexandrubordei/go-gpt3/sse"
+)
+
+// the largest response body InspectResponse buffers to read its metadata
+const maxInspectedBodySize = 4 << 20
+
+// ResponseInfo is the metadata of a response, gathered by InspectResponse while its body is read
+type ResponseInfo struct {
+	// Model is the model that generated the response
+	Model string
+	// PromptTokens, CompletionTokens and TotalTokens are taken from the usage of the response. Streamed
+	// responses don't report their usage.
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// FinishReasons are the finish reasons of the choices of the response, by choice index
+	FinishReasons []string
+	// Events is the number of data events of a streamed response
+	Events int
+	// FirstEvent is when the first data event of a streamed response was read
+	FirstEvent time.Time
+}
+
+// InspectResponse wraps the body of a successful response so that onClose is called with the metadata
+// of the response once its body has been read and closed. Middleware uses it to observe the usage of a
+// call without decoding the response itself.
+func InspectResponse(resp *http.Response, stream bool, onClose func(ResponseInfo)) {
+	body := &inspectedBody{
+		ReadCloser: resp.Body,
+		stream:     stream,
+		onClose:    onClose,
+	}
+	if stream {
+		body.events = sse.NewDecoder(body.readEvent)
+	}
+	resp.Body = body
+}
+
+type inspectedBody struct {
+	io.ReadCloser
+	stream  bool
+	onClose func(ResponseInfo)
+	once    sync.Once
+
+	// the events of streamed responses are decoded as they are read, other responses are buffered
+	events *sse.Decoder
+	buf    bytes.Buffer
+	info   ResponseInfo
+}
+
+// the subset of the completion, chat completion, edits and embeddings responses read by InspectResponse
+type responseMetadata struct {
+	Model string `json:"model"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Choices []struct {
+		Index        int     `json:"index"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+func (b *inspectedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if b.events != nil {
+			b.events.Write(p[:n])
+		} else if b.buf.Len() <= maxInspectedBodySize {
+			b.buf.Write(p[:n])
+		}
+	}
+	return n, err
+}
+
+// readEvent reads the metadata of an event of the stream
+func (b *inspectedBody) readEvent(event sse.Event) {
+	if event.Type != sse.DefaultEventType || strings.TrimSpace(event.Data) == doneData {
+		return
+	}
+	if b.info.Events == 0 {
+		b.info.FirstEvent = time.Now()
+	}
+	b.info.Events++
+	b.readMetadata([]byte(event.Data))
+}
+
+func (b *inspectedBody) readMetadata(data []byte) {
+	var metadata responseMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return
+	}
+	if metadata.Model != "" {
+		b.info.Model = metadata.Model
+	}
+	if metadata.Usage != nil {
+		b.info.PromptTokens = metadata.Usage.PromptTokens
+		b.info.CompletionTokens = metadata.Usage.CompletionTokens
+		b.info.TotalTokens = metadata.Usage.TotalTokens
+	}
+	for _, choice := range metadata.Choices {
+		if choice.FinishReason == nil || *choice.FinishReason == "" || choice.Index < 0 {
+			continue
+		}
+		for len(b.info.FinishReasons) <= choice.Index {
+			b.info.FinishReasons = append(b.info.FinishReasons, "")
+		}
+		b.info.FinishReasons[choice.Index] = *choice.FinishReason
+	}
+}
+
+func (b *inspectedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if !b.stream && b.buf.Len() <= maxInspectedBodySize {
+			b.readMetadata(b.buf.Bytes())
+		}
+		b.onClose(b.info)
+	})
+	return err
+}
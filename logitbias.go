@@ -0,0 +1,85 @@
+package gpt3
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// the bounds of the bias of a token
+const (
+	MinLogitBias = -100
+	MaxLogitBias = 100
+)
+
+// LogitBias maps token ids to a bias from -100 to 100 added to their logits before sampling. Values
+// between -1 and 1 slightly change the likelihood of the tokens, while -100 bans them and 100 makes them
+// the only choice.
+type LogitBias map[string]float32
+
+// TokenEncoder encodes text into the token ids of a model, like the encodings of the tokenizer module
+type TokenEncoder interface {
+	Encode(text string) []int
+}
+
+// LogitBiasBuilder builds a LogitBias from token ids or from text encoded with a TokenEncoder
+type LogitBiasBuilder struct {
+	encoder TokenEncoder
+	bias    LogitBias
+	err     error
+}
+
+// NewLogitBiasBuilder returns a builder encoding text with the encoder, which must match the model of
+// the request. The encoder may be nil when only token ids are biased.
+func NewLogitBiasBuilder(encoder TokenEncoder) *LogitBiasBuilder {
+	return &LogitBiasBuilder{encoder: encoder, bias: LogitBias{}}
+}
+
+// Token biases the token id. The bias is clamped to -100..100, and a NaN bias fails the builder.
+func (b *LogitBiasBuilder) Token(id int, bias float32) *LogitBiasBuilder {
+	if math.IsNaN(float64(bias)) {
+		if b.err == nil {
+			b.err = fmt.Errorf("logit bias of token %d is NaN", id)
+		}
+		return b
+	}
+	b.bias[strconv.Itoa(id)] = clampLogitBias(bias)
+	return b
+}
+
+// Text biases every token of the encoded text. The bias is clamped to -100..100. Note that words are
+// usually encoded with their leading space, so " yes" and "yes" are different tokens.
+func (b *LogitBiasBuilder) Text(text string, bias float32) *LogitBiasBuilder {
+	if b.encoder == nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("logit bias of %q needs a token encoder", text)
+		}
+		return b
+	}
+	for _, id := range b.encoder.Encode(text) {
+		b.Token(id, bias)
+	}
+	return b
+}
+
+// Build returns the logit bias, or the first error of the builder
+func (b *LogitBiasBuilder) Build() (LogitBias, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	bias := make(LogitBias, len(b.bias))
+	for id, value := range b.bias {
+		bias[id] = value
+	}
+	return bias, nil
+}
+
+func clampLogitBias(bias float32) float32 {
+	if bias < MinLogitBias {
+		return MinLogitBias
+	}
+	if bias > MaxLogitBias {
+		return MaxLogitBias
+	}
+	return bias
+}
@@ -0,0 +1,47 @@
+package gpt3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEncoder encodes text into the ids of its words
+type fakeEncoder map[string][]int
+
+func (e fakeEncoder) Encode(text string) []int {
+	return e[text]
+}
+
+func TestLogitBiasBuilder(t *testing.T) {
+	encoder := fakeEncoder{" yes": {3763}, " no": {645}, " maybe": {743, 88}}
+
+	bias, err := NewLogitBiasBuilder(encoder).
+		Text(" yes", 5).
+		Text(" maybe", -1000).
+		Token(50256, 250.5).
+		Token(645, -0.5).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, LogitBias{
+		"3763":  5,
+		"743":   -100,
+		"88":    -100,
+		"50256": 100,
+		"645":   -0.5,
+	}, bias)
+
+	bias, err = NewLogitBiasBuilder(nil).Token(50256, -100).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, LogitBias{"50256": -100}, bias)
+
+	_, err = NewLogitBiasBuilder(nil).Text(" yes", 1).Token(50256, -100).Build()
+	assert.EqualError(t, err, `logit bias of " yes" needs a token encoder`)
+
+	nan := float32(math.NaN())
+	_, err = NewLogitBiasBuilder(nil).Token(50256, nan).Build()
+	assert.EqualError(t, err, "logit bias of token 50256 is NaN")
+	_, err = NewLogitBiasBuilder(encoder).Text(" maybe", nan).Build()
+	assert.EqualError(t, err, "logit bias of token 743 is NaN")
+}
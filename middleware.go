@@ -0,0 +1,80 @@
+package gpt3
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// Call is a single API call made by a client method, as seen by middleware
+type Call struct {
+	// Operation is the name of the client method that made the call, e.g. "Completion" or "CreateEmbeddings"
+	Operation string
+	// Payload is the request passed to the client method, e.g. a CompletionRequest. It is nil for calls
+	// without a request body.
+	Payload interface{}
+	// Stream is set when the response body is a stream of server-sent events
+	Stream bool
+	// Request is the http request of the call. Middleware may modify it, or replace it with a request
+	// derived from it, before calling the next RoundTripFunc.
+	Request *http.Request
+	// Attempts is the number of times the request was sent, including retries. It is set once the next
+	// RoundTripFunc returned.
+	Attempts int
+}
+
+// Model returns the model requested by the payload of the call, or an empty string when the payload
+// doesn't have a model, like the completions of an engine.
+func (c *Call) Model() string {
+	v := reflect.Indirect(reflect.ValueOf(c.Payload))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if field := v.FieldByName("Model"); field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
+
+// RoundTripFunc performs a call and returns its response. Unsuccessful responses are returned as an
+// APIError, and the body of a successful response is read by the client method once it is returned.
+type RoundTripFunc func(call *Call) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc, letting it inspect or change calls and their responses
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+type callKey struct{}
+
+type callInfo struct {
+	operation string
+	payload   interface{}
+}
+
+// withCall stores the operation and payload of the call in the context of its request
+func withCall(ctx context.Context, operation string, payload interface{}) context.Context {
+	return context.WithValue(ctx, callKey{}, callInfo{operation: operation, payload: payload})
+}
+
+// performCall runs the request through the middleware of the client, the innermost RoundTripFunc performs
+// it with the rate limiter and retry policy. Streamed requests are returned once their stream started.
+func (c *client) performCall(req *http.Request, stream bool) (*http.Response, error) {
+	info, _ := req.Context().Value(callKey{}).(callInfo)
+	call := &Call{
+		Operation: info.operation,
+		Payload:   info.payload,
+		Stream:    stream,
+		Request:   req,
+	}
+
+	var ready func(*http.Response) error
+	if stream {
+		ready = waitForStreamStart
+	}
+	var next RoundTripFunc = func(call *Call) (*http.Response, error) {
+		return c.performRetryableRequest(call, ready)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+	return next(call)
+}
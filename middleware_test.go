@@ -0,0 +1,202 @@
+package gpt3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+
+	var calls []Call
+	var order []string
+	recorder := func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*http.Response, error) {
+			order = append(order, "recorder")
+			calls = append(calls, *call)
+			return next(call)
+		}
+	}
+	headers := func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*http.Response, error) {
+			order = append(order, "headers")
+			call.Request.Header.Set("Authorization", "Bearer rotated-key")
+			call.Request.Header.Set("X-Trace", call.Operation)
+			return next(call)
+		}
+	}
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithMiddleware(recorder), WithMiddleware(headers))
+
+	t.Run("sees the operation and payload", func(t *testing.T) {
+		calls, order = nil, nil
+		rt.RoundTripReturns(statusResponse(200, nil, `{"id":"123"}`), nil)
+
+		request := CompletionRequest{Prompt: PromptString("hello")}
+		_, err := client.Completion(ctx, request)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"recorder", "headers"}, order)
+		assert.Len(t, calls, 1)
+		assert.Equal(t, "Completion", calls[0].Operation)
+		assert.Equal(t, request, calls[0].Payload)
+		assert.False(t, calls[0].Stream)
+
+		req := rt.RoundTripArgsForCall(rt.RoundTripCallCount() - 1)
+		assert.Equal(t, "Bearer rotated-key", req.Header.Get("Authorization"))
+		assert.Equal(t, "Completion", req.Header.Get("X-Trace"))
+	})
+
+	t.Run("wraps embeddings", func(t *testing.T) {
+		calls = nil
+		rt.RoundTripReturns(statusResponse(200, nil, `{"object":"list"}`), nil)
+
+		_, err := client.CreateEmbeddings(ctx, "text-embedding-ada-002", []string{"text"})
+		assert.NoError(t, err)
+		assert.Equal(t, "CreateEmbeddings", calls[0].Operation)
+		assert.Equal(t, EmbeddingsRequest{Model: "text-embedding-ada-002", Input: []string{"text"}}, calls[0].Payload)
+	})
+
+	t.Run("wraps file uploads", func(t *testing.T) {
+		calls = nil
+		rt.RoundTripReturns(statusResponse(200, nil, `{"id":"file-123"}`), nil)
+
+		dir, err := ioutil.TempDir("", "gpt3")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "data.jsonl")
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"prompt":"a","completion":"b"}`), os.ModePerm))
+		_, err = client.UploadFile(ctx, filename, FineTunePurpose)
+		assert.NoError(t, err)
+		assert.Equal(t, "UploadFile", calls[0].Operation)
+		assert.Equal(t, FileUploadRequest{File: filename, Purpose: FineTunePurpose}, calls[0].Payload)
+	})
+
+	t.Run("reports the operation of the called method", func(t *testing.T) {
+		calls = nil
+		rt.RoundTripReturnsOnCall(rt.RoundTripCallCount(), statusResponse(200, nil, `{"id":"ft-123"}`), nil)
+		rt.RoundTripReturnsOnCall(rt.RoundTripCallCount()+1, statusResponse(200, nil, `{"id":"ft-124"}`), nil)
+
+		_, err := client.CreateFineTune(ctx, "file-123")
+		assert.NoError(t, err)
+		_, err = client.CreateFineTuneWithOptions(ctx, FineTuneOptions{TrainingFile: "file-123"})
+		assert.NoError(t, err)
+		assert.Equal(t, "CreateFineTune", calls[0].Operation)
+		assert.Equal(t, "CreateFineTuneWithOptions", calls[1].Operation)
+	})
+
+	t.Run("wraps streams", func(t *testing.T) {
+		calls = nil
+		rt.RoundTripReturns(statusResponse(200, nil, "data: {\"id\":\"1\"}\n\ndata: [DONE]\n\n"), nil)
+
+		var ids []string
+		err := client.ChatCompletionStream(ctx, ChatCompletionRequest{}, func(rsp *ChatCompletionStreamResponse) {
+			ids = append(ids, rsp.ID)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, ids)
+		assert.Equal(t, "ChatCompletionStream", calls[0].Operation)
+		assert.True(t, calls[0].Stream)
+	})
+
+	t.Run("sees api errors", func(t *testing.T) {
+		var seen error
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+			return func(call *Call) (*http.Response, error) {
+				resp, err := next(call)
+				seen = err
+				return resp, err
+			}
+		}))
+		rt.RoundTripReturns(statusResponse(401, nil, `{"error":{"type":"invalid_request_error","message":"bad key"}}`), nil)
+
+		_, err := client.ListModels(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, err, seen)
+	})
+
+	t.Run("can short circuit calls", func(t *testing.T) {
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+			return func(call *Call) (*http.Response, error) {
+				return statusResponse(200, nil, `{"id":"cached"}`), nil
+			}
+		}))
+		count := rt.RoundTripCallCount()
+
+		rsp, err := client.GetModel(ctx, "ada")
+		assert.NoError(t, err)
+		assert.Equal(t, "cached", rsp.ID)
+		assert.Equal(t, count, rt.RoundTripCallCount())
+	})
+}
+
+func TestCallAttempts(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+
+	var call *Call
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 1}),
+		WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+			return func(c *Call) (*http.Response, error) {
+				call = c
+				return next(c)
+			}
+		}))
+	rt.RoundTripReturnsOnCall(0, statusResponse(503, nil, `{"error":{"type":"server_error"}}`), nil)
+	rt.RoundTripReturnsOnCall(1, statusResponse(200, nil, `{"id":"123"}`), nil)
+
+	_, err := client.ChatCompletion(ctx, ChatCompletionRequest{Model: GPT3Dot5Turbo})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, call.Attempts)
+	assert.Equal(t, GPT3Dot5Turbo, call.Model())
+}
+
+func TestInspectResponse(t *testing.T) {
+	t.Run("reads the usage of a response", func(t *testing.T) {
+		var info *ResponseInfo
+		resp := statusResponse(200, nil, `{
+			"model": "text-davinci-003",
+			"choices": [{"index": 1, "finish_reason": "length"}, {"index": 0, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 7, "total_tokens": 12}
+		}`)
+		InspectResponse(resp, false, func(i ResponseInfo) {
+			info = &i
+		})
+
+		output := new(CompletionResponse)
+		assert.NoError(t, getResponseObject(resp, output))
+		assert.Equal(t, &ResponseInfo{
+			Model:            "text-davinci-003",
+			PromptTokens:     5,
+			CompletionTokens: 7,
+			TotalTokens:      12,
+			FinishReasons:    []string{"stop", "length"},
+		}, info)
+	})
+
+	t.Run("reads the events of a stream", func(t *testing.T) {
+		var info *ResponseInfo
+		resp := statusResponse(200, nil, "data: {\"model\":\"gpt-3.5-turbo\",\"choices\":[{\"index\":0}]}\n\n"+
+			"data: {\"choices\":[{\"index\":0,\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+		InspectResponse(resp, true, func(i ResponseInfo) {
+			info = &i
+		})
+
+		_, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Nil(t, info)
+		assert.NoError(t, resp.Body.Close())
+		assert.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, "gpt-3.5-turbo", info.Model)
+		assert.Equal(t, 2, info.Events)
+		assert.Equal(t, []string{"stop"}, info.FinishReasons)
+		assert.False(t, info.FirstEvent.IsZero())
+	})
+}
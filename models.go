@@ -1,16 +1,27 @@
 package gpt3
 
-import "fmt"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
 
-// APIError represents an error that occured on an API
+// APIError represents an error that occured on an API. It matches the sentinel errors like
+// ErrRateLimited or ErrModelNotFound with errors.Is.
 type APIError struct {
 	StatusCode int    `json:"status_code"`
 	Message    string `json:"message"`
 	Type       string `json:"type"`
-}
-
-func (e APIError) Error() string {
-	return fmt.Sprintf("[%d:%s] %s", e.StatusCode, e.Type, e.Message)
+	// The request parameter the error relates to, if any
+	Param string `json:"param"`
+	// A machine readable error code such as "context_length_exceeded", if any
+	Code string `json:"code"`
+	// The value of the X-Request-Id response header, useful when contacting support
+	RequestID string `json:"-"`
+	// The raw response body
+	Body string `json:"-"`
 }
 
 // APIErrorResponse is the full error respnose that has been returned by an API.
@@ -32,13 +43,57 @@ type EnginesResponse struct {
 	Object string         `json:"object"`
 }
 
+// ModelPermission describes what an organization is allowed to do with a model
+type ModelPermission struct {
+	ID                 string  `json:"id"`
+	Object             string  `json:"object"`
+	Created            int     `json:"created"`
+	AllowCreateEngine  bool    `json:"allow_create_engine"`
+	AllowSampling      bool    `json:"allow_sampling"`
+	AllowLogprobs      bool    `json:"allow_logprobs"`
+	AllowSearchIndices bool    `json:"allow_search_indices"`
+	AllowView          bool    `json:"allow_view"`
+	AllowFineTuning    bool    `json:"allow_fine_tuning"`
+	Organization       string  `json:"organization"`
+	Group              *string `json:"group"`
+	IsBlocking         bool    `json:"is_blocking"`
+}
+
+// Model is returned from the Models API
+type Model struct {
+	ID         string            `json:"id"`
+	Object     string            `json:"object"`
+	Created    int               `json:"created"`
+	OwnedBy    string            `json:"owned_by"`
+	Permission []ModelPermission `json:"permission"`
+	// The model this model was derived from, for base models this is the model itself
+	Root string `json:"root"`
+	// The parent model of a fine-tuned model, nil for base models
+	Parent *string `json:"parent"`
+}
+
+// ModelsResponse is returned from the ListModels API
+type ModelsResponse struct {
+	Data   []Model `json:"data"`
+	Object string  `json:"object"`
+}
+
+// ModelDeleteResponse is returned from the DeleteModel API
+type ModelDeleteResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
 // CompletionRequest is a request for the completions API
 type CompletionRequest struct {
-	// A list of string prompts to use.
-	// TODO there are other prompt types here for using token integers that we could add support for.
-	Prompt string `json:"prompt"`
-	//for edits
-	Suffix *string `json:"suffix"`
+	// ID of the model to use. When set, Completion and CompletionStream send the model in the body
+	// to the /completions endpoint instead of using the engine in the url path.
+	Model string `json:"model,omitempty"`
+	// The prompt to complete, see PromptString, PromptStrings, PromptTokens and PromptTokenBatches
+	Prompt Prompt `json:"prompt"`
+	// The suffix that comes after a completion of inserted text
+	Suffix *string `json:"suffix,omitempty"`
 	// How many tokens to complete up to. Max of 512
 	MaxTokens *int `json:"max_tokens,omitempty"`
 	// Sampling temperature to use
@@ -46,17 +101,24 @@ type CompletionRequest struct {
 	// Alternative to temperature for nucleus sampling
 	TopP *float32 `json:"top_p,omitempty"`
 	// How many choice to create for each prompt
-	N *int `json:"n"`
+	N *int `json:"n,omitempty"`
 	// Include the probabilities of most likely tokens
-	LogProbs *int `json:"logprobs"`
+	LogProbs *int `json:"logprobs,omitempty"`
 	// Echo back the prompt in addition to the completion
-	Echo bool `json:"echo"`
+	Echo bool `json:"echo,omitempty"`
 	// Up to 4 sequences where the API will stop generating tokens. Response will not contain the stop sequence.
 	Stop []string `json:"stop,omitempty"`
 	// PresencePenalty number between 0 and 1 that penalizes tokens that have already appeared in the text so far.
-	PresencePenalty float32 `json:"presence_penalty"`
+	PresencePenalty float32 `json:"presence_penalty,omitempty"`
 	// FrequencyPenalty number between 0 and 1 that penalizes tokens on existing frequency in the text so far.
-	FrequencyPenalty float32 `json:"frequency_penalty"`
+	FrequencyPenalty float32 `json:"frequency_penalty,omitempty"`
+	// Generates best_of completions server-side and returns the best n of them. Must be greater than n,
+	// and can't be used when streaming.
+	BestOf *int `json:"best_of,omitempty"`
+	// Modify the likelihood of specified tokens appearing in the completion, see NewLogitBiasBuilder
+	LogitBias LogitBias `json:"logit_bias,omitempty"`
+	// A unique identifier representing your end-user
+	User string `json:"user,omitempty"`
 
 	// Whether to stream back results or not. Don't set this value in the request yourself
 	// as it will be overriden depending on if you use CompletionStream or Completion methods.
@@ -93,6 +155,17 @@ type CompletionResponseChoice struct {
 	Index        int           `json:"index"`
 	LogProbs     LogprobResult `json:"logprobs"`
 	FinishReason string        `json:"finish_reason"`
+	// The index of the prompt of the request this choice was generated for, when several prompts were
+	// completed in a batch. It is set by Completion and CompletionStream.
+	PromptIndex int `json:"-"`
+}
+
+// CompletionResponseUsage is the object that returns how many tokens the completion's request used.
+// It is not set in the chunks of streamed completions.
+type CompletionResponseUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // CompletionResponse is the full response from a request to the completions API
@@ -102,6 +175,109 @@ type CompletionResponse struct {
 	Created int                        `json:"created"`
 	Model   string                     `json:"model"`
 	Choices []CompletionResponseChoice `json:"choices"`
+	Usage   CompletionResponseUsage    `json:"usage"`
+}
+
+// ChoicesForPrompt returns the choices generated for the prompt at the given index of the request
+func (r *CompletionResponse) ChoicesForPrompt(index int) []CompletionResponseChoice {
+	var choices []CompletionResponseChoice
+	for _, choice := range r.Choices {
+		if choice.PromptIndex == index {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// Chat message roles
+const (
+	ChatRoleSystem    = "system"
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatCompletionRequestMessage is a single message in a chat conversation
+type ChatCompletionRequestMessage struct {
+	// The role of the author of this message. One of system, user, or assistant.
+	Role string `json:"role"`
+	// The contents of the message
+	Content string `json:"content"`
+	// The name of the author of this message. May contain a-z, A-Z, 0-9, and underscores.
+	Name string `json:"name,omitempty"`
+}
+
+// ChatCompletionRequest is a request for the chat completions API
+type ChatCompletionRequest struct {
+	// ID of the model to use. Defaults to DefaultChatModel when empty.
+	Model string `json:"model"`
+	// The messages to generate chat completions for
+	Messages []ChatCompletionRequestMessage `json:"messages"`
+	// Sampling temperature to use
+	Temperature *float32 `json:"temperature,omitempty"`
+	// Alternative to temperature for nucleus sampling
+	TopP *float32 `json:"top_p,omitempty"`
+	// How many chat completion choices to generate for each input message
+	N *int `json:"n,omitempty"`
+	// Up to 4 sequences where the API will stop generating further tokens
+	Stop []string `json:"stop,omitempty"`
+	// The maximum number of tokens allowed for the generated answer
+	MaxTokens *int `json:"max_tokens,omitempty"`
+	// PresencePenalty number between -2.0 and 2.0 that penalizes tokens that have already appeared in the text so far.
+	PresencePenalty float32 `json:"presence_penalty,omitempty"`
+	// FrequencyPenalty number between -2.0 and 2.0 that penalizes tokens on existing frequency in the text so far.
+	FrequencyPenalty float32 `json:"frequency_penalty,omitempty"`
+	// A unique identifier representing your end-user
+	User string `json:"user,omitempty"`
+
+	// Whether to stream back results or not. Don't set this value in the request yourself
+	// as it will be overriden depending on if you use ChatCompletionStream or ChatCompletion methods.
+	Stream bool `json:"stream,omitempty"`
+}
+
+// ChatCompletionResponseMessage is a message returned in the response to the chat completions API
+type ChatCompletionResponseMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionResponseChoice is one of the choices returned in the response to the chat completions API
+type ChatCompletionResponseChoice struct {
+	Index        int                           `json:"index"`
+	Message      ChatCompletionResponseMessage `json:"message"`
+	FinishReason string                        `json:"finish_reason"`
+}
+
+// ChatCompletionsResponseUsage is the object that returns how many tokens the chat completion's request used
+type ChatCompletionsResponseUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionResponse is the full response from a request to the chat completions API
+type ChatCompletionResponse struct {
+	ID      string                         `json:"id"`
+	Object  string                         `json:"object"`
+	Created int                            `json:"created"`
+	Model   string                         `json:"model"`
+	Choices []ChatCompletionResponseChoice `json:"choices"`
+	Usage   ChatCompletionsResponseUsage   `json:"usage"`
+}
+
+// ChatCompletionStreamResponseChoice is one of the choices returned in a streamed chunk of the chat completions API
+type ChatCompletionStreamResponseChoice struct {
+	Index        int                           `json:"index"`
+	Delta        ChatCompletionResponseMessage `json:"delta"`
+	FinishReason string                        `json:"finish_reason"`
+}
+
+// ChatCompletionStreamResponse is a single chunk streamed back from the chat completions API
+type ChatCompletionStreamResponse struct {
+	ID      string                               `json:"id"`
+	Object  string                               `json:"object"`
+	Created int                                  `json:"created"`
+	Model   string                               `json:"model"`
+	Choices []ChatCompletionStreamResponseChoice `json:"choices"`
 }
 
 // EditsResponse is the full response from a request to the edits API
@@ -144,6 +320,127 @@ type SearchResponse struct {
 	Object string       `json:"object"`
 }
 
+// AnswersRequest is a request for the answers API
+type AnswersRequest struct {
+	// ID of the model to use for completion
+	Model string `json:"model"`
+	// Question to get answered
+	Question string `json:"question"`
+	// List of (question, answer) pairs that will help steer the model towards the tone and answer format you'd like
+	Examples [][]string `json:"examples"`
+	// A text snippet containing the contextual information used to generate the answers for the examples
+	ExamplesContext string `json:"examples_context"`
+	// List of documents from which the answer for the input question should be derived.
+	// Only one of Documents or File should be set.
+	Documents []string `json:"documents,omitempty"`
+	// The ID of an uploaded file that contains documents to search over, uploaded with the AnswersPurpose
+	File string `json:"file,omitempty"`
+	// ID of the model to use for search. Defaults to ada.
+	SearchModel string `json:"search_model,omitempty"`
+	// The maximum number of documents to be ranked by search when using File
+	MaxRerank *int `json:"max_rerank,omitempty"`
+	// Sampling temperature to use
+	Temperature *float32 `json:"temperature,omitempty"`
+	// Include the probabilities of most likely tokens
+	LogProbs *int `json:"logprobs,omitempty"`
+	// The maximum number of tokens allowed for the generated answer
+	MaxTokens *int `json:"max_tokens,omitempty"`
+	// Up to 4 sequences where the API will stop generating further tokens
+	Stop []string `json:"stop,omitempty"`
+	// How many answers to generate for each question
+	N *int `json:"n,omitempty"`
+	// Modify the likelihood of specified tokens appearing in the completion
+	LogitBias LogitBias `json:"logit_bias,omitempty"`
+	// Whether to include the metadata of the documents in the response when using File
+	ReturnMetadata bool `json:"return_metadata,omitempty"`
+	// Whether to include the prompt used to generate the answer in the response
+	ReturnPrompt bool `json:"return_prompt,omitempty"`
+	// Fields of the completion that should be expanded in the response, e.g. "completion" or "file"
+	Expand []string `json:"expand,omitempty"`
+	// A unique identifier representing your end-user
+	User string `json:"user,omitempty"`
+}
+
+// AnswersResponseDocument is a document selected by search to answer the question
+type AnswersResponseDocument struct {
+	Document int    `json:"document"`
+	Object   string `json:"object,omitempty"`
+	Text     string `json:"text"`
+	// The metadata of the document's line of the uploaded file, which can be any JSON value
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// AnswersResponse is the full response from a request to the answers API
+type AnswersResponse struct {
+	Object            string                    `json:"object"`
+	Model             string                    `json:"model"`
+	SearchModel       string                    `json:"search_model"`
+	Completion        string                    `json:"completion"`
+	Answers           []string                  `json:"answers"`
+	SelectedDocuments []AnswersResponseDocument `json:"selected_documents"`
+	Prompt            string                    `json:"prompt,omitempty"`
+}
+
+// ClassificationsRequest is a request for the classifications API
+type ClassificationsRequest struct {
+	// ID of the model to use for completion
+	Model string `json:"model"`
+	// Query to be classified
+	Query string `json:"query"`
+	// A list of examples with labels, in the format [text, label].
+	// Only one of Examples or File should be set.
+	Examples [][]string `json:"examples,omitempty"`
+	// The ID of an uploaded file that contains labeled examples, uploaded with the ClassificationsPurpose
+	File string `json:"file,omitempty"`
+	// The set of categories being classified. If not specified, the labels are inferred from the examples.
+	Labels []string `json:"labels,omitempty"`
+	// ID of the model to use for search. Defaults to ada.
+	SearchModel string `json:"search_model,omitempty"`
+	// Sampling temperature to use
+	Temperature *float32 `json:"temperature,omitempty"`
+	// Include the probabilities of most likely tokens
+	LogProbs *int `json:"logprobs,omitempty"`
+	// The maximum number of examples to be ranked by search when using File
+	MaxExamples *int `json:"max_examples,omitempty"`
+	// Modify the likelihood of specified tokens appearing in the completion
+	LogitBias LogitBias `json:"logit_bias,omitempty"`
+	// Whether to include the metadata of the examples in the response when using File
+	ReturnMetadata bool `json:"return_metadata,omitempty"`
+	// Whether to include the prompt used to classify the query in the response
+	ReturnPrompt bool `json:"return_prompt,omitempty"`
+	// Fields of the completion that should be expanded in the response, e.g. "completion" or "file"
+	Expand []string `json:"expand,omitempty"`
+	// A unique identifier representing your end-user
+	User string `json:"user,omitempty"`
+}
+
+// ClassificationsResponseExample is an example selected by search to classify the query
+type ClassificationsResponseExample struct {
+	Document int    `json:"document"`
+	Object   string `json:"object,omitempty"`
+	Label    string `json:"label"`
+	Text     string `json:"text"`
+	// The metadata of the example's line of the uploaded file, which can be any JSON value
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// ClassificationsResponse is the full response from a request to the classifications API
+type ClassificationsResponse struct {
+	Object           string                           `json:"object"`
+	Model            string                           `json:"model"`
+	SearchModel      string                           `json:"search_model"`
+	Completion       string                           `json:"completion"`
+	Label            string                           `json:"label"`
+	SelectedExamples []ClassificationsResponseExample `json:"selected_examples"`
+	Prompt           string                           `json:"prompt,omitempty"`
+}
+
+// FileUploadRequest describes the file uploaded by UploadFile, it is the payload of the call seen by middleware
+type FileUploadRequest struct {
+	File    string
+	Purpose string
+}
+
 type FileUploadResponse struct {
 	ID        string `json:"id"`
 	Object    string `json:"object"`
@@ -175,6 +472,18 @@ type FineTuneResponse struct {
 	FineTunedModel  *string `json:"fine_tuned_model"`
 }
 
+// FineTunesResponse is returned from the ListFineTunes API
+type FineTunesResponse struct {
+	Data   []FineTuneResponse `json:"data"`
+	Object string             `json:"object"`
+}
+
+// FineTuneEventsResponse is returned from the ListFineTuneEvents API
+type FineTuneEventsResponse struct {
+	Data   []Event `json:"data"`
+	Object string  `json:"object"`
+}
+
 type HyperParams struct {
 	BatchSize              int     `json:"batch_size"`
 	LearningRateMultiplier float64 `json:"learning_rate_multiplier"`
@@ -191,6 +500,12 @@ type File struct {
 	Purpose   string `json:"purpose"`
 }
 
+// FilesResponse is returned from the ListFiles API
+type FilesResponse struct {
+	Data   []File `json:"data"`
+	Object string `json:"object"`
+}
+
 type Event struct {
 	Object    string `json:"object"`
 	CreatedAt int    `json:"created_at"`
@@ -235,3 +550,217 @@ type Embedding struct {
 	Embedding []float64 `json:"embedding"`
 	Index     int       `json:"index"`
 }
+
+// ModerationRequest is a request for the moderations API
+type ModerationRequest struct {
+	// The input text to classify
+	Input []string `json:"input"`
+	// The moderation model to use, either TextModerationLatest or TextModerationStable.
+	// Defaults to TextModerationLatest when empty.
+	Model string `json:"model,omitempty"`
+}
+
+// ModerationCategories contains whether the input was flagged for each of the policy categories
+type ModerationCategories struct {
+	Hate            bool `json:"hate"`
+	HateThreatening bool `json:"hate/threatening"`
+	SelfHarm        bool `json:"self-harm"`
+	Sexual          bool `json:"sexual"`
+	SexualMinors    bool `json:"sexual/minors"`
+	Violence        bool `json:"violence"`
+	ViolenceGraphic bool `json:"violence/graphic"`
+}
+
+// Flagged returns the names of the categories the input was flagged for
+func (c ModerationCategories) Flagged() []string {
+	var flagged []string
+	for _, category := range []struct {
+		name    string
+		flagged bool
+	}{
+		{"hate", c.Hate},
+		{"hate/threatening", c.HateThreatening},
+		{"self-harm", c.SelfHarm},
+		{"sexual", c.Sexual},
+		{"sexual/minors", c.SexualMinors},
+		{"violence", c.Violence},
+		{"violence/graphic", c.ViolenceGraphic},
+	} {
+		if category.flagged {
+			flagged = append(flagged, category.name)
+		}
+	}
+	return flagged
+}
+
+// ModerationCategoryScores contains the model's confidence for each of the policy categories
+type ModerationCategoryScores struct {
+	Hate            float64 `json:"hate"`
+	HateThreatening float64 `json:"hate/threatening"`
+	SelfHarm        float64 `json:"self-harm"`
+	Sexual          float64 `json:"sexual"`
+	SexualMinors    float64 `json:"sexual/minors"`
+	Violence        float64 `json:"violence"`
+	ViolenceGraphic float64 `json:"violence/graphic"`
+}
+
+// ModerationResult is the classification of a single input of the moderations API
+type ModerationResult struct {
+	Flagged        bool                     `json:"flagged"`
+	Categories     ModerationCategories     `json:"categories"`
+	CategoryScores ModerationCategoryScores `json:"category_scores"`
+}
+
+// ModerationResponse is the full response from a request to the moderations API
+type ModerationResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+// Image sizes
+const (
+	ImageSize256x256   = "256x256"
+	ImageSize512x512   = "512x512"
+	ImageSize1024x1024 = "1024x1024"
+)
+
+// Image response formats
+const (
+	ImageResponseFormatURL     = "url"
+	ImageResponseFormatB64JSON = "b64_json"
+)
+
+// ImageRequest is a request for the image generations API
+type ImageRequest struct {
+	// A text description of the desired image(s). The maximum length is 1000 characters.
+	Prompt string `json:"prompt"`
+	// The number of images to generate. Must be between 1 and 10.
+	N *int `json:"n,omitempty"`
+	// The size of the generated images. Must be one of 256x256, 512x512, or 1024x1024.
+	Size string `json:"size,omitempty"`
+	// The format in which the generated images are returned. Must be one of url or b64_json.
+	ResponseFormat string `json:"response_format,omitempty"`
+	// A unique identifier representing your end-user
+	User string `json:"user,omitempty"`
+}
+
+// ImageEditRequest is a request for the image edits API
+type ImageEditRequest struct {
+	// The PNG image to edit. Must be a valid square PNG file, less than 4MB. If mask is not provided,
+	// the image must have transparency, which will be used as the mask.
+	Image io.Reader
+	// An optional PNG image whose fully transparent areas indicate where the image should be edited.
+	// Must have the same dimensions as image.
+	Mask io.Reader
+	// A text description of the desired image(s). The maximum length is 1000 characters.
+	Prompt string
+	// The number of images to generate. Must be between 1 and 10.
+	N *int
+	// The size of the generated images. Must be one of 256x256, 512x512, or 1024x1024.
+	Size string
+	// The format in which the generated images are returned. Must be one of url or b64_json.
+	ResponseFormat string
+	// A unique identifier representing your end-user
+	User string
+}
+
+// ImageVariationRequest is a request for the image variations API
+type ImageVariationRequest struct {
+	// The PNG image to use as the basis for the variation(s). Must be a valid square PNG file, less than 4MB.
+	Image io.Reader
+	// The number of images to generate. Must be between 1 and 10.
+	N *int
+	// The size of the generated images. Must be one of 256x256, 512x512, or 1024x1024.
+	Size string
+	// The format in which the generated images are returned. Must be one of url or b64_json.
+	ResponseFormat string
+	// A unique identifier representing your end-user
+	User string
+}
+
+// ImageResponseData is a single image returned by the image APIs. Depending on the requested
+// response format either URL or B64JSON is set.
+type ImageResponseData struct {
+	URL     string `json:"url,omitempty"`
+	B64JSON string `json:"b64_json,omitempty"`
+}
+
+// Decode returns the image bytes of a b64_json image
+func (d ImageResponseData) Decode() ([]byte, error) {
+	if d.B64JSON == "" {
+		return nil, fmt.Errorf("image has no b64_json data")
+	}
+	data, err := base64.StdEncoding.DecodeString(d.B64JSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid b64_json data: %w", err)
+	}
+	return data, nil
+}
+
+// WriteToFile decodes a b64_json image and writes it to the named file
+func (d ImageResponseData) WriteToFile(filename string) error {
+	data, err := d.Decode()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// ImageResponse is the full response from a request to the image APIs
+type ImageResponse struct {
+	Created int                 `json:"created"`
+	Data    []ImageResponseData `json:"data"`
+}
+
+// Audio response formats
+const (
+	AudioResponseFormatJSON        = "json"
+	AudioResponseFormatText        = "text"
+	AudioResponseFormatSRT         = "srt"
+	AudioResponseFormatVTT         = "vtt"
+	AudioResponseFormatVerboseJSON = "verbose_json"
+)
+
+// AudioRequest is a request for the audio transcriptions and translations APIs
+type AudioRequest struct {
+	// The audio file to transcribe, in one of these formats: mp3, mp4, mpeg, mpga, m4a, wav, or webm.
+	File io.Reader
+	// The name of the audio file. The extension is used to detect the format of the audio.
+	FileName string
+	// ID of the model to use. Defaults to Whisper1 when empty.
+	Model string
+	// An optional text to guide the model's style or continue a previous audio segment.
+	Prompt string
+	// The language of the input audio in ISO-639-1 format. Only supported by transcriptions.
+	Language string
+	// Sampling temperature to use
+	Temperature *float32
+	// The format of the transcript output, one of json, text, srt, verbose_json, or vtt. Defaults to json.
+	ResponseFormat string
+}
+
+// AudioSegment is a segment of the transcribed audio, returned with the verbose_json response format
+type AudioSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+	Transient        bool    `json:"transient"`
+}
+
+// AudioResponse is the full response from a request to the audio APIs. For the text, srt and vtt
+// response formats only Text is set and contains the raw response.
+type AudioResponse struct {
+	Task     string         `json:"task,omitempty"`
+	Language string         `json:"language,omitempty"`
+	Duration float64        `json:"duration,omitempty"`
+	Text     string         `json:"text"`
+	Segments []AudioSegment `json:"segments,omitempty"`
+}
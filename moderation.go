@@ -0,0 +1,89 @@
+package gpt3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ModerationGuardOptions configures the moderation guard enabled by WithModerationGuard
+type ModerationGuardOptions struct {
+	// The moderation model to use. Defaults to TextModerationLatest when empty.
+	Model string
+	// Whether to screen the generated text of non-streamed completions as well as the prompt
+	ScreenOutput bool
+}
+
+// ModerationBlockedError is returned when the moderation guard flags a prompt or a completion
+type ModerationBlockedError struct {
+	// Whether the generated text was flagged rather than the prompt
+	Output bool
+	// The names of the categories that were flagged
+	Categories []string
+	// The results returned by the moderations API for every screened input
+	Results []ModerationResult
+}
+
+func (e *ModerationBlockedError) Error() string {
+	source := "prompt"
+	if e.Output {
+		source = "completion"
+	}
+	return fmt.Sprintf("%s blocked by moderation: %s", source, strings.Join(e.Categories, ", "))
+}
+
+// screenPrompt checks the text prompts of the request with the moderation guard, if enabled. Token id
+// prompts can't be screened.
+func (c *client) screenPrompt(ctx context.Context, request CompletionRequest) error {
+	texts := promptTexts(request.Prompt)
+	if c.moderationGuard == nil || len(texts) == 0 {
+		return nil
+	}
+	return c.screen(ctx, texts, false)
+}
+
+// screenCompletion checks the generated text of a completion with the moderation guard, if enabled
+func (c *client) screenCompletion(ctx context.Context, completion *CompletionResponse) error {
+	if c.moderationGuard == nil || !c.moderationGuard.ScreenOutput || len(completion.Choices) == 0 {
+		return nil
+	}
+	input := make([]string, len(completion.Choices))
+	for i, choice := range completion.Choices {
+		input[i] = choice.Text
+	}
+	return c.screen(ctx, input, true)
+}
+
+func (c *client) screen(ctx context.Context, input []string, output bool) error {
+	rsp, err := c.Moderation(ctx, ModerationRequest{
+		Input: input,
+		Model: c.moderationGuard.Model,
+	})
+	if err != nil {
+		return fmt.Errorf("moderation guard: %w", err)
+	}
+
+	flagged := false
+	var categories []string
+	seen := map[string]bool{}
+	for _, result := range rsp.Results {
+		if !result.Flagged {
+			continue
+		}
+		flagged = true
+		for _, category := range result.Categories.Flagged() {
+			if !seen[category] {
+				seen[category] = true
+				categories = append(categories, category)
+			}
+		}
+	}
+	if !flagged {
+		return nil
+	}
+	return &ModerationBlockedError{
+		Output:     output,
+		Categories: categories,
+		Results:    rsp.Results,
+	}
+}
@@ -0,0 +1,231 @@
+// Package otelgpt3 instruments the gpt3 client with OpenTelemetry. Every call made by the client is
+// traced with a span and measured with latency histograms and error counters.
+package otelgpt3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	gpt3 "github.com/alexandrubordei/go-gpt3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/alexandrubordei/go-gpt3/otelgpt3"
+
+// Attribute keys of the spans and metrics, following the OpenTelemetry semantic conventions for
+// generative AI where they exist
+const (
+	OperationKey        = attribute.Key("gen_ai.operation.name")
+	SystemKey           = attribute.Key("gen_ai.system")
+	RequestModelKey     = attribute.Key("gen_ai.request.model")
+	ResponseModelKey    = attribute.Key("gen_ai.response.model")
+	InputTokensKey      = attribute.Key("gen_ai.usage.input_tokens")
+	OutputTokensKey     = attribute.Key("gen_ai.usage.output_tokens")
+	FinishReasonsKey    = attribute.Key("gen_ai.response.finish_reasons")
+	StatusCodeKey       = attribute.Key("http.response.status_code")
+	ErrorTypeKey        = attribute.Key("error.type")
+	StreamKey           = attribute.Key("gpt3.stream")
+	RetryCountKey       = attribute.Key("gpt3.retry_count")
+	StreamEventCountKey = attribute.Key("gpt3.stream.events")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the instrumentation
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider of the spans, the global one is used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the MeterProvider of the metrics, the global one is used by default
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithInstrumentation is a client option that instruments every call of the client
+func WithInstrumentation(opts ...Option) gpt3.ClientOption {
+	return gpt3.WithMiddleware(Middleware(opts...))
+}
+
+// Middleware returns the middleware instrumenting the calls of a client. It emits a span per call, named
+// after the client method, and records:
+//
+//   - gpt3.client.duration, a histogram of the duration of the calls in seconds, until the response was
+//     read completely
+//   - gpt3.client.time_to_first_token, a histogram of the seconds until the first event of streamed calls
+//   - gpt3.client.errors, a counter of the failed calls
+func Middleware(opts ...Option) gpt3.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	i := newInstrumentation(cfg)
+
+	return func(next gpt3.RoundTripFunc) gpt3.RoundTripFunc {
+		return func(call *gpt3.Call) (*http.Response, error) {
+			return i.roundTrip(next, call)
+		}
+	}
+}
+
+type instrumentation struct {
+	tracer           trace.Tracer
+	duration         metric.Float64Histogram
+	timeToFirstToken metric.Float64Histogram
+	errors           metric.Int64Counter
+}
+
+func newInstrumentation(cfg config) *instrumentation {
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	i := &instrumentation{
+		tracer: cfg.tracerProvider.Tracer(instrumentationName),
+	}
+
+	// failing instruments are reported to the global error handler and replaced by no-ops
+	var err error
+	i.duration, err = meter.Float64Histogram(
+		"gpt3.client.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the calls to the OpenAI API, until the response was read completely"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	i.timeToFirstToken, err = meter.Float64Histogram(
+		"gpt3.client.time_to_first_token",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time until the first event of streamed calls to the OpenAI API"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	i.errors, err = meter.Int64Counter(
+		"gpt3.client.errors",
+		metric.WithUnit("{error}"),
+		metric.WithDescription("Number of failed calls to the OpenAI API"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	return i
+}
+
+func (i *instrumentation) roundTrip(next gpt3.RoundTripFunc, call *gpt3.Call) (*http.Response, error) {
+	start := time.Now()
+	attrs := []attribute.KeyValue{
+		OperationKey.String(call.Operation),
+		SystemKey.String("openai"),
+	}
+	if model := call.Model(); model != "" {
+		attrs = append(attrs, RequestModelKey.String(model))
+	}
+
+	ctx, span := i.tracer.Start(call.Request.Context(), "gpt3."+call.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(StreamKey.Bool(call.Stream)),
+	)
+	call.Request = call.Request.WithContext(ctx)
+
+	resp, err := next(call)
+	span.SetAttributes(RetryCountKey.Int(retryCount(call)))
+	if err != nil {
+		errAttrs := []attribute.KeyValue{ErrorTypeKey.String(errorType(err))}
+		if code := statusCode(err); code != 0 {
+			errAttrs = append(errAttrs, StatusCodeKey.Int(code))
+		}
+		attrs = append(attrs, errAttrs...)
+		span.SetAttributes(errAttrs...)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+
+		i.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+		i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		return nil, err
+	}
+
+	attrs = append(attrs, StatusCodeKey.Int(resp.StatusCode))
+	span.SetAttributes(StatusCodeKey.Int(resp.StatusCode))
+	gpt3.InspectResponse(resp, call.Stream, func(info gpt3.ResponseInfo) {
+		if info.Model != "" {
+			span.SetAttributes(ResponseModelKey.String(info.Model))
+		}
+		if info.PromptTokens > 0 || info.CompletionTokens > 0 {
+			span.SetAttributes(
+				InputTokensKey.Int(info.PromptTokens),
+				OutputTokensKey.Int(info.CompletionTokens),
+			)
+		}
+		if len(info.FinishReasons) > 0 {
+			span.SetAttributes(FinishReasonsKey.StringSlice(info.FinishReasons))
+		}
+		if call.Stream {
+			span.SetAttributes(StreamEventCountKey.Int(info.Events))
+			if !info.FirstEvent.IsZero() {
+				span.AddEvent("first token", trace.WithTimestamp(info.FirstEvent))
+				i.timeToFirstToken.Record(ctx, info.FirstEvent.Sub(start).Seconds(), metric.WithAttributes(attrs...))
+			}
+		}
+		span.End()
+		i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	})
+	return resp, nil
+}
+
+func retryCount(call *gpt3.Call) int {
+	if call.Attempts < 1 {
+		return 0
+	}
+	return call.Attempts - 1
+}
+
+func statusCode(err error) int {
+	var apiErr gpt3.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// errorType returns a low cardinality description of err
+func errorType(err error) string {
+	var apiErr gpt3.APIError
+	var transportErr *gpt3.TransportError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.As(err, &apiErr):
+		if apiErr.Code != "" {
+			return apiErr.Code
+		}
+		if apiErr.Type != "" {
+			return apiErr.Type
+		}
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.As(err, &transportErr):
+		return "transport"
+	}
+	return "other"
+}
@@ -0,0 +1,169 @@
+package otelgpt3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	gpt3 "github.com/alexandrubordei/go-gpt3"
+	fakes "github.com/alexandrubordei/go-gpt3/go-gpt3fakes"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func response(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+type testInstrumentation struct {
+	client gpt3.Client
+	rt     *fakes.FakeRoundTripper
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+}
+
+func newTestInstrumentation(options ...gpt3.ClientOption) *testInstrumentation {
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	rt := &fakes.FakeRoundTripper{}
+
+	options = append([]gpt3.ClientOption{
+		gpt3.WithHTTPClient(&http.Client{Transport: rt}),
+		WithInstrumentation(
+			WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		),
+	}, options...)
+	return &testInstrumentation{
+		client: gpt3.NewClient("test-key", options...),
+		rt:     rt,
+		spans:  spans,
+		reader: reader,
+	}
+}
+
+func (ti *testInstrumentation) metrics(t *testing.T) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, ti.reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestCompletion(t *testing.T) {
+	ti := newTestInstrumentation()
+	ti.rt.RoundTripReturns(response(200, `{
+		"id": "cmpl-123",
+		"model": "text-davinci-003",
+		"choices": [{"text": "hi", "index": 0, "finish_reason": "length"}],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 7, "total_tokens": 12}
+	}`), nil)
+
+	_, err := ti.client.Completion(context.Background(), gpt3.CompletionRequest{Model: "text-davinci-003", Prompt: gpt3.PromptString("hello")})
+	assert.NoError(t, err)
+
+	spans := ti.spans.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "gpt3.Completion", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "Completion", attrs[OperationKey].AsString())
+	assert.Equal(t, "text-davinci-003", attrs[RequestModelKey].AsString())
+	assert.Equal(t, "text-davinci-003", attrs[ResponseModelKey].AsString())
+	assert.Equal(t, int64(5), attrs[InputTokensKey].AsInt64())
+	assert.Equal(t, int64(7), attrs[OutputTokensKey].AsInt64())
+	assert.Equal(t, []string{"length"}, attrs[FinishReasonsKey].AsStringSlice())
+	assert.Equal(t, int64(200), attrs[StatusCodeKey].AsInt64())
+	assert.Equal(t, int64(0), attrs[RetryCountKey].AsInt64())
+
+	// the span is propagated to the transport
+	req := ti.rt.RoundTripArgsForCall(0)
+	assert.Equal(t, spans[0].SpanContext.SpanID(), trace.SpanContextFromContext(req.Context()).SpanID())
+
+	metrics := ti.metrics(t)
+	duration := metrics["gpt3.client.duration"].(metricdata.Histogram[float64])
+	assert.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+	_, ok := metrics["gpt3.client.errors"]
+	assert.False(t, ok)
+}
+
+func TestStream(t *testing.T) {
+	ti := newTestInstrumentation()
+	ti.rt.RoundTripReturns(response(200, `data: {"model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{"content":"Hello"}}]}
+
+data: {"model":"gpt-3.5-turbo","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`), nil)
+
+	err := ti.client.ChatCompletionStream(context.Background(), gpt3.ChatCompletionRequest{}, func(*gpt3.ChatCompletionStreamResponse) {})
+	assert.NoError(t, err)
+
+	spans := ti.spans.GetSpans()
+	assert.Len(t, spans, 1)
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "gpt3.ChatCompletionStream", spans[0].Name)
+	assert.True(t, attrs[StreamKey].AsBool())
+	assert.Equal(t, gpt3.DefaultChatModel, attrs[RequestModelKey].AsString())
+	assert.Equal(t, int64(2), attrs[StreamEventCountKey].AsInt64())
+	assert.Equal(t, []string{"stop"}, attrs[FinishReasonsKey].AsStringSlice())
+	assert.Len(t, spans[0].Events, 1)
+
+	metrics := ti.metrics(t)
+	ttft := metrics["gpt3.client.time_to_first_token"].(metricdata.Histogram[float64])
+	assert.Len(t, ttft.DataPoints, 1)
+	assert.Equal(t, uint64(1), ttft.DataPoints[0].Count)
+}
+
+func TestErrors(t *testing.T) {
+	ti := newTestInstrumentation(gpt3.WithRetryPolicy(gpt3.RetryPolicy{MaxAttempts: 3, BaseDelay: 1}))
+	ti.rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return response(429, `{"error":{"type":"requests","message":"Rate limit reached"}}`), nil
+	}
+
+	_, err := ti.client.CreateEmbeddings(context.Background(), "text-embedding-ada-002", []string{"text"})
+	assert.Error(t, err)
+	assert.Equal(t, 3, ti.rt.RoundTripCallCount())
+
+	spans := ti.spans.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "CreateEmbeddings", attrs[OperationKey].AsString())
+	assert.Equal(t, int64(429), attrs[StatusCodeKey].AsInt64())
+	assert.Equal(t, "requests", attrs[ErrorTypeKey].AsString())
+	assert.Equal(t, int64(2), attrs[RetryCountKey].AsInt64())
+
+	metrics := ti.metrics(t)
+	errors := metrics["gpt3.client.errors"].(metricdata.Sum[int64])
+	assert.Len(t, errors.DataPoints, 1)
+	assert.Equal(t, int64(1), errors.DataPoints[0].Value)
+	errorType, _ := errors.DataPoints[0].Attributes.Value(ErrorTypeKey)
+	assert.Equal(t, "requests", errorType.AsString())
+}
@@ -0,0 +1,58 @@
+package gpt3
+
+import "strings"
+
+// ModelPrice is the price of a model in dollars per 1000 tokens
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// PriceTable maps model names to their price. A model without an exact entry uses the longest entry that
+// is a prefix of its name, which covers dated snapshots like gpt-3.5-turbo-0301. Fine-tuned models like
+// curie:ft-acme-2021-03-03-21-44-20 are billed at their own usage rate, priced by the entries of their
+// base model followed by ":ft-".
+type PriceTable map[string]ModelPrice
+
+// DefaultPriceTable has the published prices of the OpenAI models as of March 2023
+var DefaultPriceTable = PriceTable{
+	"gpt-4":                  {Prompt: 0.03, Completion: 0.06},
+	"gpt-4-32k":              {Prompt: 0.06, Completion: 0.12},
+	"gpt-3.5-turbo":          {Prompt: 0.002, Completion: 0.002},
+	"text-davinci":           {Prompt: 0.02, Completion: 0.02},
+	"davinci":                {Prompt: 0.02, Completion: 0.02},
+	"text-curie":             {Prompt: 0.002, Completion: 0.002},
+	"curie":                  {Prompt: 0.002, Completion: 0.002},
+	"text-babbage":           {Prompt: 0.0005, Completion: 0.0005},
+	"babbage":                {Prompt: 0.0005, Completion: 0.0005},
+	"text-ada":               {Prompt: 0.0004, Completion: 0.0004},
+	"ada":                    {Prompt: 0.0004, Completion: 0.0004},
+	"davinci:ft-":            {Prompt: 0.12, Completion: 0.12},
+	"curie:ft-":              {Prompt: 0.012, Completion: 0.012},
+	"babbage:ft-":            {Prompt: 0.0024, Completion: 0.0024},
+	"ada:ft-":                {Prompt: 0.0016, Completion: 0.0016},
+	"text-embedding-ada-002": {Prompt: 0.0004},
+	"text-moderation":        {},
+}
+
+// Price returns the price of the model, and whether the table has a price for it
+func (t PriceTable) Price(model string) (ModelPrice, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+	var price ModelPrice
+	longest := -1
+	for name, p := range t {
+		if len(name) > longest && strings.HasPrefix(model, name) {
+			price, longest = p, len(name)
+		}
+	}
+	return price, longest >= 0
+}
+
+// Cost returns the price in dollars of the tokens used by a request to the model, zero when the table
+// has no price for it
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, _ := t.Price(model)
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1000
+}
@@ -0,0 +1,29 @@
+package gpt3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceTable(t *testing.T) {
+	price, ok := DefaultPriceTable.Price("gpt-4-32k-0314")
+	assert.True(t, ok)
+	assert.Equal(t, ModelPrice{Prompt: 0.06, Completion: 0.12}, price)
+
+	// fine-tuned models are billed at their usage rate, not at the price of their base model
+	price, ok = DefaultPriceTable.Price("curie:ft-acme-2021-03-03-21-44-20")
+	assert.True(t, ok)
+	assert.Equal(t, ModelPrice{Prompt: 0.012, Completion: 0.012}, price)
+	price, ok = DefaultPriceTable.Price("davinci:ft-personal-2023-03-01-10-00-00")
+	assert.True(t, ok)
+	assert.Equal(t, ModelPrice{Prompt: 0.12, Completion: 0.12}, price)
+	price, _ = DefaultPriceTable.Price("curie")
+	assert.Equal(t, ModelPrice{Prompt: 0.002, Completion: 0.002}, price)
+
+	_, ok = DefaultPriceTable.Price("whisper-1")
+	assert.False(t, ok)
+
+	assert.InDelta(t, 0.03*1.5+0.06*0.5, DefaultPriceTable.Cost("gpt-4-0314", 1500, 500), 1e-9)
+	assert.Equal(t, 0.0, DefaultPriceTable.Cost("whisper-1", 1500, 500))
+}
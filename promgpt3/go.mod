@@ -0,0 +1,24 @@
+module github.com/alexandrubordei/go-gpt3/promgpt3
+
+go 1.20
+
+replace github.com/alexandrubordei/go-gpt3 => ../
+
+require (
+	github.com/alexandrubordei/go-gpt3 v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.19.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
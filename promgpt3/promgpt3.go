@@ -0,0 +1,228 @@
+// Package promgpt3 exports Prometheus metrics about the calls made by the gpt3 client: request counts,
+// latencies, token usage, estimated spend and streams in flight.
+package promgpt3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	gpt3 "github.com/alexandrubordei/go-gpt3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type config struct {
+	namespace  string
+	prices     gpt3.PriceTable
+	buckets    []float64
+	constLabel prometheus.Labels
+}
+
+// Option configures a Collector
+type Option func(*config)
+
+// WithNamespace sets the namespace of the metric names, "gpt3" by default
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithPriceTable sets the prices used to estimate the spend, gpt3.DefaultPriceTable by default
+func WithPriceTable(prices gpt3.PriceTable) Option {
+	return func(c *config) {
+		c.prices = prices
+	}
+}
+
+// WithBuckets sets the buckets of the request duration histogram, in seconds
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithConstLabels adds constant labels to all the metrics, e.g. to tell several clients apart
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabel = labels
+	}
+}
+
+// the default buckets of the request duration, completions can easily take tens of seconds
+var defaultBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80}
+
+// Collector is a prometheus.Collector of the metrics of the calls made by the clients it is added to with
+// WithCollector. It collects:
+//
+//   - gpt3_requests_total, a counter of the calls by method, model and status
+//   - gpt3_request_duration_seconds, a histogram of the duration of the calls by method and model, until
+//     the response was read completely
+//   - gpt3_tokens_total, a counter of the tokens reported by the usage of the responses, by model and type
+//     (prompt or completion)
+//   - gpt3_estimated_spend_dollars_total, a counter of the dollars spent on the tokens, by model
+//   - gpt3_streams_in_flight, a gauge of the streams being read, by method and model
+//
+// The status label is the http status code of the response, "canceled" when the context of the call was
+// canceled, or "error" when no response was received.
+type Collector struct {
+	prices   gpt3.PriceTable
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	tokens   *prometheus.CounterVec
+	spend    *prometheus.CounterVec
+	streams  *prometheus.GaugeVec
+}
+
+// NewCollector returns a new Collector, which has to be registered with a prometheus.Registerer
+func NewCollector(opts ...Option) *Collector {
+	cfg := config{
+		namespace: "gpt3",
+		prices:    gpt3.DefaultPriceTable,
+		buckets:   defaultBuckets,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &Collector{
+		prices: cfg.prices,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "requests_total",
+			Help:        "Number of calls to the OpenAI API.",
+			ConstLabels: cfg.constLabel,
+		}, []string{"method", "model", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of the calls to the OpenAI API, until the response was read completely.",
+			Buckets:     cfg.buckets,
+			ConstLabels: cfg.constLabel,
+		}, []string{"method", "model"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "tokens_total",
+			Help:        "Number of tokens used by the calls to the OpenAI API.",
+			ConstLabels: cfg.constLabel,
+		}, []string{"model", "type"}),
+		spend: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "estimated_spend_dollars_total",
+			Help:        "Estimated dollars spent on the tokens used by the calls to the OpenAI API.",
+			ConstLabels: cfg.constLabel,
+		}, []string{"model"}),
+		streams: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Name:        "streams_in_flight",
+			Help:        "Number of streamed responses of the OpenAI API being read.",
+			ConstLabels: cfg.constLabel,
+		}, []string{"method", "model"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.tokens.Describe(ch)
+	c.spend.Describe(ch)
+	c.streams.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.tokens.Collect(ch)
+	c.spend.Collect(ch)
+	c.streams.Collect(ch)
+}
+
+// WithCollector is a client option that records the calls of the client with the collector
+func WithCollector(collector *Collector) gpt3.ClientOption {
+	return gpt3.WithMiddleware(collector.Middleware())
+}
+
+// WithMetrics is a client option that records the calls of the client with a new Collector registered with
+// reg. When reg already has a Collector with the same namespace and labels, like one created for another
+// client, the calls are recorded with it instead. Like prometheus.MustRegister, it panics when the registration fails
+// for any other reason.
+func WithMetrics(reg prometheus.Registerer, opts ...Option) gpt3.ClientOption {
+	collector := NewCollector(opts...)
+	if err := reg.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			panic(err)
+		}
+		existing, ok := registered.ExistingCollector.(*Collector)
+		if !ok {
+			panic(err)
+		}
+		collector = existing
+	}
+	return WithCollector(collector)
+}
+
+// Middleware returns the middleware recording the calls of a client with the collector
+func (c *Collector) Middleware() gpt3.Middleware {
+	return func(next gpt3.RoundTripFunc) gpt3.RoundTripFunc {
+		return func(call *gpt3.Call) (*http.Response, error) {
+			return c.roundTrip(next, call)
+		}
+	}
+}
+
+func (c *Collector) roundTrip(next gpt3.RoundTripFunc, call *gpt3.Call) (*http.Response, error) {
+	start := time.Now()
+	resp, err := next(call)
+	if err != nil {
+		c.observe(call.Operation, call.Model(), status(err), start)
+		return nil, err
+	}
+
+	model := call.Model()
+	if call.Stream {
+		c.streams.WithLabelValues(call.Operation, model).Inc()
+	}
+	gpt3.InspectResponse(resp, call.Stream, func(info gpt3.ResponseInfo) {
+		if call.Stream {
+			c.streams.WithLabelValues(call.Operation, model).Dec()
+		}
+		if model == "" {
+			model = info.Model
+		}
+		c.observe(call.Operation, model, strconv.Itoa(resp.StatusCode), start)
+
+		if info.PromptTokens > 0 || info.CompletionTokens > 0 {
+			// the usage is priced by the model that generated the response, like a dated snapshot
+			priced := info.Model
+			if priced == "" {
+				priced = model
+			}
+			c.tokens.WithLabelValues(model, "prompt").Add(float64(info.PromptTokens))
+			c.tokens.WithLabelValues(model, "completion").Add(float64(info.CompletionTokens))
+			c.spend.WithLabelValues(model).Add(c.prices.Cost(priced, info.PromptTokens, info.CompletionTokens))
+		}
+	})
+	return resp, nil
+}
+
+func (c *Collector) observe(method, model, status string, start time.Time) {
+	c.requests.WithLabelValues(method, model, status).Inc()
+	c.duration.WithLabelValues(method, model).Observe(time.Since(start).Seconds())
+}
+
+// status returns the status label of a failed call
+func status(err error) string {
+	var apiErr gpt3.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "error"
+}
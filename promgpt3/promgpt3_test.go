@@ -0,0 +1,109 @@
+package promgpt3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	gpt3 "github.com/alexandrubordei/go-gpt3"
+	fakes "github.com/alexandrubordei/go-gpt3/go-gpt3fakes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func response(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func newTestClient(collector *Collector) (gpt3.Client, *fakes.FakeRoundTripper) {
+	rt := &fakes.FakeRoundTripper{}
+	client := gpt3.NewClient("test-key",
+		gpt3.WithHTTPClient(&http.Client{Transport: rt}),
+		WithCollector(collector),
+	)
+	return client, rt
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	collector := NewCollector()
+	client, rt := newTestClient(collector)
+
+	rt.RoundTripReturns(response(200, `{
+		"model": "gpt-4-0314",
+		"choices": [{"index": 0, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500}
+	}`), nil)
+	_, err := client.ChatCompletion(ctx, gpt3.ChatCompletionRequest{Model: "gpt-4"})
+	assert.NoError(t, err)
+
+	rt.RoundTripReturns(response(401, `{"error":{"type":"invalid_request_error","message":"bad key"}}`), nil)
+	_, err = client.ChatCompletion(ctx, gpt3.ChatCompletionRequest{Model: "gpt-4"})
+	assert.Error(t, err)
+
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP gpt3_requests_total Number of calls to the OpenAI API.
+# TYPE gpt3_requests_total counter
+gpt3_requests_total{method="ChatCompletion",model="gpt-4",status="200"} 1
+gpt3_requests_total{method="ChatCompletion",model="gpt-4",status="401"} 1
+# HELP gpt3_tokens_total Number of tokens used by the calls to the OpenAI API.
+# TYPE gpt3_tokens_total counter
+gpt3_tokens_total{model="gpt-4",type="completion"} 500
+gpt3_tokens_total{model="gpt-4",type="prompt"} 1000
+`), "gpt3_requests_total", "gpt3_tokens_total"))
+
+	assert.InDelta(t, 0.03+0.03, testutil.ToFloat64(collector.spend.WithLabelValues("gpt-4")), 1e-9)
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "gpt3_request_duration_seconds"))
+}
+
+func TestCollectorStreams(t *testing.T) {
+	ctx := context.Background()
+	collector := NewCollector()
+	client, rt := newTestClient(collector)
+
+	rt.RoundTripReturns(response(200, "data: {\"model\":\"gpt-3.5-turbo-0301\",\"choices\":[{\"index\":0}]}\n\ndata: [DONE]\n\n"), nil)
+	err := client.ChatCompletionStream(ctx, gpt3.ChatCompletionRequest{}, func(*gpt3.ChatCompletionStreamResponse) {
+		assert.Equal(t, 1.0, testutil.ToFloat64(collector.streams.WithLabelValues("ChatCompletionStream", gpt3.DefaultChatModel)))
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.streams.WithLabelValues("ChatCompletionStream", gpt3.DefaultChatModel)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.requests.WithLabelValues("ChatCompletionStream", gpt3.DefaultChatModel, "200")))
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	rt := &fakes.FakeRoundTripper{}
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return response(200, `{"model":"text-davinci-003","usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`), nil
+	}
+
+	// clients registering with the same registry share their collector
+	for i := 0; i < 2; i++ {
+		client := gpt3.NewClient("test-key", gpt3.WithHTTPClient(&http.Client{Transport: rt}), WithMetrics(reg))
+		_, err := client.Completion(ctx, gpt3.CompletionRequest{})
+		assert.NoError(t, err)
+	}
+
+	count, err := testutil.GatherAndCount(reg, "gpt3_requests_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP gpt3_requests_total Number of calls to the OpenAI API.
+# TYPE gpt3_requests_total counter
+gpt3_requests_total{method="Completion",model="text-davinci-003",status="200"} 2
+`), "gpt3_requests_total"))
+
+	// metrics with the same names and different labels can't be registered
+	assert.Panics(t, func() {
+		WithMetrics(reg, WithConstLabels(prometheus.Labels{"client": "other"}))
+	})
+}
@@ -0,0 +1,192 @@
+package gpt3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type promptKind int
+
+const (
+	promptString promptKind = iota
+	promptStrings
+	promptTokens
+	promptTokenBatches
+)
+
+// Prompt is the prompt of a CompletionRequest, created with one of PromptString, PromptStrings,
+// PromptTokens and PromptTokenBatches. The zero value is the empty string prompt.
+//
+// Every prompt of a batch gets N choices, see CompletionResponseChoice.PromptIndex to map them back to
+// their prompt.
+type Prompt struct {
+	kind   promptKind
+	text   string
+	texts  []string
+	tokens [][]int
+}
+
+// PromptString returns a prompt of a single text
+func PromptString(text string) Prompt {
+	return Prompt{kind: promptString, text: text}
+}
+
+// PromptStrings returns a batch of text prompts, completed in a single request
+func PromptStrings(texts ...string) Prompt {
+	return Prompt{kind: promptStrings, texts: texts}
+}
+
+// PromptTokens returns a prompt of token ids
+func PromptTokens(tokens ...int) Prompt {
+	return Prompt{kind: promptTokens, tokens: [][]int{tokens}}
+}
+
+// PromptTokenBatches returns a batch of token id prompts, completed in a single request
+func PromptTokenBatches(batches ...[]int) Prompt {
+	return Prompt{kind: promptTokenBatches, tokens: batches}
+}
+
+// Len returns how many prompts are completed for the prompt
+func (p Prompt) Len() int {
+	switch p.kind {
+	case promptStrings:
+		return len(p.texts)
+	case promptTokenBatches:
+		return len(p.tokens)
+	default:
+		return 1
+	}
+}
+
+// Texts returns the prompts given as text, or nil for token id prompts
+func (p Prompt) Texts() []string {
+	switch p.kind {
+	case promptString:
+		return []string{p.text}
+	case promptStrings:
+		return p.texts
+	default:
+		return nil
+	}
+}
+
+// Tokens returns the prompts given as token ids, or nil for text prompts
+func (p Prompt) Tokens() [][]int {
+	if p.kind == promptTokens || p.kind == promptTokenBatches {
+		return p.tokens
+	}
+	return nil
+}
+
+// MarshalJSON encodes the prompt in the form the API accepts: a string, an array of strings, an array of
+// token ids or an array of token id arrays
+func (p Prompt) MarshalJSON() ([]byte, error) {
+	switch p.kind {
+	case promptStrings:
+		if p.texts == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(p.texts)
+	case promptTokens:
+		if p.tokens[0] == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(p.tokens[0])
+	case promptTokenBatches:
+		if p.tokens == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(p.tokens)
+	default:
+		return json.Marshal(p.text)
+	}
+}
+
+// UnmarshalJSON decodes any of the forms written by MarshalJSON. An empty array is decoded as an empty
+// batch of text prompts.
+func (p *Prompt) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*p = Prompt{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*p = PromptString(text)
+		return nil
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return fmt.Errorf("invalid prompt, expected a string or an array: %w", err)
+	}
+	if len(elements) == 0 {
+		*p = PromptStrings()
+		return nil
+	}
+	switch first := bytes.TrimSpace(elements[0]); {
+	case len(first) > 0 && first[0] == '"':
+		var texts []string
+		if err := json.Unmarshal(data, &texts); err != nil {
+			return err
+		}
+		*p = PromptStrings(texts...)
+	case len(first) > 0 && first[0] == '[':
+		var batches [][]int
+		if err := json.Unmarshal(data, &batches); err != nil {
+			return err
+		}
+		*p = PromptTokenBatches(batches...)
+	default:
+		var tokens []int
+		if err := json.Unmarshal(data, &tokens); err != nil {
+			return err
+		}
+		*p = PromptTokens(tokens...)
+	}
+	return nil
+}
+
+// promptTexts returns the non empty prompts given as text, token id prompts have no text
+func promptTexts(prompt Prompt) []string {
+	var nonEmpty []string
+	for _, text := range prompt.Texts() {
+		if text != "" {
+			nonEmpty = append(nonEmpty, text)
+		}
+	}
+	return nonEmpty
+}
+
+// estimatePromptTokens estimates the tokens of the text prompts and counts the tokens of the token id
+// prompts
+func estimatePromptTokens(prompt Prompt) int {
+	tokens := 0
+	for _, text := range prompt.Texts() {
+		tokens += estimateTextTokens(text)
+	}
+	for _, ids := range prompt.Tokens() {
+		tokens += len(ids)
+	}
+	return tokens
+}
+
+// choicesPerPrompt returns the n of the request, the number of choices generated for each prompt
+func (r CompletionRequest) choicesPerPrompt() int {
+	if r.N != nil && *r.N > 0 {
+		return *r.N
+	}
+	return 1
+}
+
+// setPromptIndexes sets the index of the prompt each choice was generated for. The API returns the n
+// choices of the first prompt, followed by the n choices of the second prompt and so on.
+func setPromptIndexes(choices []CompletionResponseChoice, n int) {
+	for i := range choices {
+		choices[i].PromptIndex = choices[i].Index / n
+	}
+}
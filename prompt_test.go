@@ -0,0 +1,135 @@
+package gpt3
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestPrompt(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name   string
+		prompt Prompt
+		sent   string
+	}{
+		{"string", PromptString("hello"), `"hello"`},
+		{"strings", PromptStrings("hello", "world"), `["hello","world"]`},
+		{"tokens", PromptTokens(31373, 995), `[31373,995]`},
+		{"token arrays", PromptTokenBatches([]int{31373}, []int{995}), `[[31373],[995]]`},
+		{"zero value", Prompt{}, `""`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rt, httpClient := fakeHttpClient()
+			client := NewClient("test-key", WithHTTPClient(httpClient))
+			rt.RoundTripReturns(jsonResponse(t, &CompletionResponse{}), nil)
+
+			_, err := client.Completion(ctx, CompletionRequest{Prompt: tc.prompt})
+			assert.NoError(t, err)
+			body, err := ioutil.ReadAll(rt.RoundTripArgsForCall(0).Body)
+			assert.NoError(t, err)
+			sent := map[string]json.RawMessage{}
+			assert.NoError(t, json.Unmarshal(body, &sent))
+			assert.Equal(t, tc.sent, string(sent["prompt"]))
+		})
+	}
+
+	t.Run("survives a json round trip", func(t *testing.T) {
+		for _, prompt := range []Prompt{
+			{},
+			PromptString("hello"),
+			PromptStrings("hello", "world"),
+			PromptStrings(),
+			PromptTokens(31373, 995),
+			PromptTokenBatches([]int{31373}, []int{995, 0}),
+		} {
+			request := CompletionRequest{Model: AdaEngine, Prompt: prompt}
+			data, err := json.Marshal(request)
+			assert.NoError(t, err)
+			var decoded CompletionRequest
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, request, decoded, string(data))
+		}
+
+		var request CompletionRequest
+		assert.NoError(t, json.Unmarshal([]byte(`{"prompt":null}`), &request))
+		assert.Equal(t, Prompt{}, request.Prompt)
+		assert.Error(t, json.Unmarshal([]byte(`{"prompt":42}`), &request))
+		assert.Error(t, json.Unmarshal([]byte(`{"prompt":[1,"two"]}`), &request))
+	})
+
+	t.Run("counts and reads the prompts", func(t *testing.T) {
+		assert.Equal(t, 1, Prompt{}.Len())
+		assert.Equal(t, []string{""}, Prompt{}.Texts())
+		assert.Equal(t, 2, PromptStrings("hello", "world").Len())
+		assert.Nil(t, PromptStrings("hello").Tokens())
+		assert.Equal(t, 1, PromptTokens(1, 2).Len())
+		assert.Equal(t, [][]int{{1, 2}}, PromptTokens(1, 2).Tokens())
+		assert.Nil(t, PromptTokens(1, 2).Texts())
+		assert.Equal(t, 3, PromptTokenBatches([]int{1}, []int{2}, []int{3}).Len())
+	})
+
+	t.Run("maps choices to their prompt", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(jsonResponse(t, &CompletionResponse{
+			Choices: []CompletionResponseChoice{
+				{Text: "Paris", Index: 0},
+				{Text: "Paris!", Index: 1},
+				{Text: "Tokyo", Index: 2},
+				{Text: "Tokyo!", Index: 3},
+			},
+		}), nil)
+
+		rsp, err := client.Completion(ctx, CompletionRequest{
+			Prompt: PromptStrings("The capital of France is", "The capital of Japan is"),
+			N:      IntPtr(2),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 0, 1, 1}, []int{
+			rsp.Choices[0].PromptIndex, rsp.Choices[1].PromptIndex, rsp.Choices[2].PromptIndex, rsp.Choices[3].PromptIndex,
+		})
+		japan := rsp.ChoicesForPrompt(1)
+		assert.Len(t, japan, 2)
+		assert.Equal(t, "Tokyo", japan[0].Text)
+		assert.Equal(t, "Tokyo!", japan[1].Text)
+		assert.Empty(t, rsp.ChoicesForPrompt(2))
+	})
+
+	t.Run("maps streamed choices to their prompt", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, "data: {\"choices\":[{\"text\":\"a\",\"index\":1}]}\n\n"+
+			"data: {\"choices\":[{\"text\":\"b\",\"index\":2}]}\n\ndata: [DONE]\n\n"), nil)
+
+		var indexes []int
+		err := client.CompletionStream(ctx, CompletionRequest{Prompt: PromptStrings("one", "two")}, func(rsp *CompletionResponse) {
+			indexes = append(indexes, rsp.Choices[0].PromptIndex)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, indexes)
+	})
+
+	t.Run("screens text prompts with the moderation guard", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithModerationGuard(ModerationGuardOptions{}))
+		rt.RoundTripReturnsOnCall(0, jsonResponse(t, &ModerationResponse{Results: []ModerationResult{{}, {}}}), nil)
+		rt.RoundTripReturnsOnCall(1, jsonResponse(t, &CompletionResponse{}), nil)
+		rt.RoundTripReturnsOnCall(2, jsonResponse(t, &CompletionResponse{}), nil)
+
+		_, err := client.Completion(ctx, CompletionRequest{Prompt: PromptStrings("one", "", "two")})
+		assert.NoError(t, err)
+		sent := ModerationRequest{}
+		assert.NoError(t, json.NewDecoder(rt.RoundTripArgsForCall(0).Body).Decode(&sent))
+		assert.Equal(t, []string{"one", "two"}, sent.Input)
+
+		// token prompts can't be screened
+		_, err = client.Completion(ctx, CompletionRequest{Prompt: PromptTokens(31373, 995)})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rt.RoundTripCallCount())
+	})
+}
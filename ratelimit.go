@@ -0,0 +1,238 @@
+package gpt3
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures the client side rate limiter enabled by WithRateLimit
+type RateLimit struct {
+	// RequestsPerMinute is the maximum number of requests sent per minute. Zero means unlimited.
+	RequestsPerMinute int
+	// TokensPerMinute is the maximum number of estimated tokens sent per minute. Zero means unlimited.
+	// The tokens of a completion are estimated from the length of the prompt and MaxTokens times N.
+	TokensPerMinute int
+	// Adaptive adjusts the limits from the x-ratelimit-limit-*, x-ratelimit-remaining-* and
+	// x-ratelimit-reset-* headers of the responses, holding back requests until the reset once the
+	// server reports that the quota is used up.
+	Adaptive bool
+}
+
+// tokenEstimator is implemented by the requests that can estimate how many tokens they will consume
+type tokenEstimator interface {
+	estimateUsage() (promptTokens, completionTokens int)
+}
+
+// the commonly used approximation of how many characters of english text make up a token
+const charsPerToken = 4
+
+// the max_tokens the API defaults to for completions
+const defaultCompletionMaxTokens = 16
+
+func estimateTextTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+func (r CompletionRequest) estimateUsage() (int, int) {
+	maxTokens := defaultCompletionMaxTokens
+	if r.MaxTokens != nil {
+		maxTokens = *r.MaxTokens
+	}
+	// best_of choices are generated and billed, of which n are returned
+	generated := r.choicesPerPrompt()
+	if r.BestOf != nil && *r.BestOf > generated {
+		generated = *r.BestOf
+	}
+	return estimatePromptTokens(r.Prompt), maxTokens * generated * r.Prompt.Len()
+}
+
+func (r ChatCompletionRequest) estimateUsage() (int, int) {
+	prompt := 0
+	for _, message := range r.Messages {
+		prompt += estimateTextTokens(message.Content)
+	}
+	n := 1
+	if r.N != nil {
+		n = *r.N
+	}
+	completion := 0
+	if r.MaxTokens != nil {
+		completion = *r.MaxTokens * n
+	}
+	return prompt, completion
+}
+
+func (r EmbeddingsRequest) estimateUsage() (int, int) {
+	prompt := 0
+	for _, input := range r.Input {
+		prompt += estimateTextTokens(input)
+	}
+	return prompt, 0
+}
+
+type tokenEstimateKey struct{}
+
+// withTokenEstimate stores the estimated tokens of the payload in the context of the request
+func withTokenEstimate(ctx context.Context, payload interface{}) context.Context {
+	if estimator, ok := payload.(tokenEstimator); ok {
+		prompt, completion := estimator.estimateUsage()
+		return context.WithValue(ctx, tokenEstimateKey{}, prompt+completion)
+	}
+	return ctx
+}
+
+func tokenEstimate(ctx context.Context) int {
+	tokens, _ := ctx.Value(tokenEstimateKey{}).(int)
+	return tokens
+}
+
+// rateLimiter holds back requests with a token bucket for requests and one for tokens.
+// A nil rateLimiter does not limit anything.
+type rateLimiter struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+	adaptive bool
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		requests: newTokenBucket(limit.RequestsPerMinute),
+		tokens:   newTokenBucket(limit.TokensPerMinute),
+		adaptive: limit.Adaptive,
+	}
+}
+
+// wait blocks until the request with the given estimated tokens is allowed to be sent
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.requests.wait(ctx, 1); err != nil {
+		return err
+	}
+	if err := l.tokens.wait(ctx, float64(tokens)); err != nil {
+		l.requests.refund(1)
+		return err
+	}
+	return nil
+}
+
+// observe adapts the limits to the rate limit headers of a response
+func (l *rateLimiter) observe(header http.Header) {
+	if l == nil || !l.adaptive {
+		return
+	}
+	l.requests.observe(header, "requests")
+	l.tokens.observe(header, "tokens")
+}
+
+// tokenBucket is a token bucket refilled continuously at limit tokens per minute, holding at most
+// limit tokens. Reservations may take the bucket below zero, later callers wait for the deficit.
+type tokenBucket struct {
+	mu        sync.Mutex
+	limit     float64
+	available float64
+	last      time.Time
+	notBefore time.Time
+	now       func() time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{
+		limit:     float64(perMinute),
+		available: float64(perMinute),
+		now:       time.Now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.Before(b.last) {
+		return
+	}
+	if !b.last.IsZero() && b.limit > 0 {
+		b.available += now.Sub(b.last).Minutes() * b.limit
+		if b.available > b.limit {
+			b.available = b.limit
+		}
+	}
+	b.last = now
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller has to wait before using them
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	start := now
+	if start.Before(b.notBefore) {
+		start = b.notBefore
+	}
+	delay := start.Sub(now)
+	if b.limit <= 0 {
+		return delay
+	}
+
+	b.refill(start)
+	b.available -= n
+	if b.available < 0 {
+		delay += time.Duration(-b.available / b.limit * float64(time.Minute))
+	}
+	return delay
+}
+
+func (b *tokenBucket) refund(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 {
+		b.available = math.Min(b.available+n, b.limit)
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context, n float64) error {
+	delay := b.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		b.refund(n)
+		return err
+	}
+	return nil
+}
+
+// observe adapts the bucket to the x-ratelimit-*-<kind> headers
+func (b *tokenBucket) observe(header http.Header, kind string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refill(now)
+	if limit, err := strconv.ParseFloat(header.Get("x-ratelimit-limit-"+kind), 64); err == nil && limit > 0 {
+		if b.limit <= 0 {
+			b.available = limit
+		}
+		b.limit = limit
+	}
+	remaining, err := strconv.ParseFloat(header.Get("x-ratelimit-remaining-"+kind), 64)
+	if err != nil {
+		return
+	}
+	if b.limit > 0 && remaining < b.available {
+		b.available = remaining
+	}
+	if remaining <= 0 {
+		// the quota is used up until the reset, when it is fully available again
+		if reset, err := time.ParseDuration(header.Get("x-ratelimit-reset-" + kind)); err == nil {
+			b.notBefore = now.Add(reset)
+			if b.limit > 0 {
+				b.available = b.limit
+				b.last = b.notBefore
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gpt3
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	bucket := newTokenBucket(60)
+	bucket.now = clock.Now
+
+	// the bucket starts full
+	assert.Equal(t, time.Duration(0), bucket.reserve(60))
+	// and refills at one token per second
+	assert.Equal(t, time.Second, bucket.reserve(1))
+	assert.Equal(t, 3*time.Second, bucket.reserve(2))
+
+	clock.now = clock.now.Add(3 * time.Second)
+	assert.Equal(t, time.Duration(0), bucket.reserve(0))
+	assert.Equal(t, 10*time.Second, bucket.reserve(10))
+
+	bucket.refund(10)
+	assert.Equal(t, time.Duration(0), bucket.reserve(0))
+
+	// the bucket never holds more than a minute worth of tokens
+	clock.now = clock.now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), bucket.reserve(60))
+	assert.Equal(t, time.Second, bucket.reserve(1))
+}
+
+func TestTokenBucketObserve(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+
+	t.Run("adopts the limit and remaining quota", func(t *testing.T) {
+		bucket := newTokenBucket(0)
+		bucket.now = clock.Now
+		assert.Equal(t, time.Duration(0), bucket.reserve(1000))
+
+		bucket.observe(http.Header{
+			"X-Ratelimit-Limit-Tokens":     []string{"600"},
+			"X-Ratelimit-Remaining-Tokens": []string{"10"},
+		}, "tokens")
+		assert.Equal(t, time.Duration(0), bucket.reserve(10))
+		assert.Equal(t, 10*time.Second, bucket.reserve(100))
+	})
+
+	t.Run("holds back requests until the reset", func(t *testing.T) {
+		bucket := newTokenBucket(3000)
+		bucket.now = clock.Now
+		bucket.observe(http.Header{
+			"X-Ratelimit-Remaining-Requests": []string{"0"},
+			"X-Ratelimit-Reset-Requests":     []string{"6m0s"},
+		}, "requests")
+		assert.Equal(t, 6*time.Minute, bucket.reserve(1))
+	})
+}
+
+func TestEstimateUsage(t *testing.T) {
+	prompt, completion := CompletionRequest{Prompt: PromptString("hello world")}.estimateUsage()
+	assert.Equal(t, []int{3, 16}, []int{prompt, completion})
+	prompt, completion = CompletionRequest{Prompt: PromptString("hello world"), MaxTokens: IntPtr(100), N: IntPtr(2)}.estimateUsage()
+	assert.Equal(t, []int{3, 100 * 2}, []int{prompt, completion})
+	prompt, completion = CompletionRequest{Prompt: PromptStrings("hello world", "hi"), N: IntPtr(2)}.estimateUsage()
+	assert.Equal(t, []int{4, 16 * 2 * 2}, []int{prompt, completion})
+	prompt, completion = CompletionRequest{Prompt: PromptString("hello world"), N: IntPtr(2), BestOf: IntPtr(5)}.estimateUsage()
+	assert.Equal(t, []int{3, 16 * 5}, []int{prompt, completion})
+	prompt, completion = CompletionRequest{Prompt: PromptTokenBatches([]int{1, 2, 3}, []int{4, 5}), MaxTokens: IntPtr(10)}.estimateUsage()
+	assert.Equal(t, []int{5, 10 * 2}, []int{prompt, completion})
+	prompt, completion = ChatCompletionRequest{
+		Messages:  []ChatCompletionRequestMessage{{Content: "hello"}, {Content: "hi"}},
+		MaxTokens: IntPtr(50),
+	}.estimateUsage()
+	assert.Equal(t, []int{3, 50}, []int{prompt, completion})
+	prompt, completion = EmbeddingsRequest{Input: []string{"text1", "text2"}}.estimateUsage()
+	assert.Equal(t, []int{4, 0}, []int{prompt, completion})
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithRateLimit(RateLimit{
+		RequestsPerMinute: 60,
+		Adaptive:          true,
+	}))
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return statusResponse(200, http.Header{
+			"X-Ratelimit-Remaining-Requests": []string{"0"},
+			"X-Ratelimit-Reset-Requests":     []string{"20ms"},
+		}, `{"id":"123"}`), nil
+	}
+
+	_, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 2, rt.RoundTripCallCount())
+}
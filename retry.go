@@ -0,0 +1,216 @@
+package gpt3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures how the client retries requests that failed with a retryable status code
+// or a transient connection error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for a request, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles with every following retry.
+	// Defaults to 500 milliseconds.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts, including delays requested with a Retry-After
+	// header. Defaults to 30 seconds.
+	MaxDelay time.Duration
+	// Jitter is the fraction of the delay, between 0 and 1, that is randomly subtracted from it so that
+	// clients failing at the same time don't retry at the same time.
+	Jitter float64
+	// RetryableStatusCodes are the response status codes that are retried. Defaults to 429, 500, 502,
+	// 503 and 504, except for 429 responses caused by an exceeded quota, see IsRetryable.
+	RetryableStatusCodes []int
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// isRetryable returns whether the error of an attempt is retried. Without RetryableStatusCodes this is
+// decided by IsRetryable. An exceeded quota is never retried, whatever the status codes.
+func (p *RetryPolicy) isRetryable(err error) bool {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return false
+	}
+	var apiErr APIError
+	if p.RetryableStatusCodes != nil && errors.As(err, &apiErr) {
+		for _, code := range p.RetryableStatusCodes {
+			if apiErr.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+	return IsRetryable(err)
+}
+
+func (p *RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return defaultRetryMaxDelay
+	}
+	return p.MaxDelay
+}
+
+// backoff returns the delay before the nth retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	max := p.maxDelay()
+
+	delay := max
+	if retry < 32 {
+		if d := base << uint(retry-1); d > 0 && d < max {
+			delay = d
+		}
+	}
+
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	if jitter > 0 {
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter returns the delay requested by the Retry-After header of the response, which is either
+// a number of seconds or an http date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// isTransientError returns whether err is a connection error that is worth retrying.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// streams are only retried until they start, so they never fail with an idle timeout here
+	var connectErr *ConnectTimeoutError
+	var firstEventErr *FirstEventTimeoutError
+	if errors.As(err, &connectErr) || errors.As(err, &firstEventErr) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// canRewind returns whether the body of the request can be sent again.
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// performRetryableRequest performs the request of the call, retrying it according to the retry policy of
+// the client, and counts the attempts in the call. When ready is set it is called with every successful
+// response before it is returned, an error from ready fails the attempt, which lets streaming requests
+// retry until their first data arrives.
+func (c *client) performRetryableRequest(call *Call, ready func(*http.Response) error) (*http.Response, error) {
+	req := call.Request
+	maxAttempts := c.retryPolicy.maxAttempts()
+	for attempt := 1; ; attempt++ {
+		call.Attempts = attempt
+		if err := c.rateLimiter.wait(req.Context(), tokenEstimate(req.Context())); err != nil {
+			return nil, err
+		}
+		resp, err := c.doWithTimeouts(req, call.Stream)
+		if err != nil {
+			err = &TransportError{Err: err}
+		} else {
+			c.rateLimiter.observe(resp.Header)
+			err = checkForSuccess(resp)
+			if err == nil && ready != nil {
+				if err = ready(resp); err != nil {
+					resp.Body.Close()
+					err = &TransportError{Err: err}
+				}
+			}
+		}
+		if err == nil {
+			return resp, nil
+		}
+
+		if attempt >= maxAttempts || req.Context().Err() != nil || !canRewind(req) || !c.retryPolicy.isRetryable(err) {
+			return nil, err
+		}
+
+		delay := c.retryPolicy.backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				delay = d
+				if max := c.retryPolicy.maxDelay(); delay > max {
+					delay = max
+				}
+			}
+		}
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
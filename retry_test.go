@@ -0,0 +1,236 @@
+package gpt3
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func statusResponse(code int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+
+	t.Run("retries retryable status codes and replays the body", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+
+		var bodies []string
+		responses := []*http.Response{
+			statusResponse(503, nil, "unavailable"),
+			statusResponse(429, http.Header{"Retry-After": []string{"0"}}, "slow down"),
+			statusResponse(200, nil, `{"id":"123"}`),
+		}
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			data, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			bodies = append(bodies, string(data))
+			return responses[len(bodies)-1], nil
+		}
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("retry me")})
+		assert.NoError(t, err)
+		assert.Equal(t, "123", rsp.ID)
+		assert.Len(t, bodies, 3)
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.Equal(t, bodies[0], bodies[2])
+		assert.Contains(t, bodies[0], "retry me")
+	})
+
+	t.Run("returns the last error once attempts are exhausted", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			return statusResponse(500, nil, `{"error":{"type":"server_error","message":"oops"}}`), nil
+		}
+
+		rsp, err := client.Engines(ctx)
+		assert.Nil(t, rsp)
+		assert.EqualError(t, err, "[500:server_error] oops")
+		assert.Equal(t, 3, rt.RoundTripCallCount())
+	})
+
+	t.Run("does not retry other status codes", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+		rt.RoundTripReturns(statusResponse(400, nil, "bad request"), nil)
+
+		_, err := client.Engines(ctx)
+		assert.EqualError(t, err, "[400:Unexpected] bad request")
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+	})
+
+	t.Run("retries transient connection errors", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+		rt.RoundTripReturnsOnCall(0, nil, syscall.ECONNRESET)
+		rt.RoundTripReturnsOnCall(1, statusResponse(200, nil, `{"id":"123"}`), nil)
+
+		rsp, err := client.Engine(ctx, DefaultEngine)
+		assert.NoError(t, err)
+		assert.Equal(t, "123", rsp.ID)
+		assert.Equal(t, 2, rt.RoundTripCallCount())
+
+		rt.RoundTripReturnsOnCall(2, nil, errors.New("permanent"))
+		_, err = client.Engine(ctx, DefaultEngine)
+		assert.Error(t, err)
+		assert.Equal(t, 3, rt.RoundTripCallCount())
+	})
+
+	t.Run("retries multipart uploads", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+
+		var bodies []string
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			data, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			bodies = append(bodies, string(data))
+			if len(bodies) == 1 {
+				return statusResponse(502, nil, "bad gateway"), nil
+			}
+			return statusResponse(200, nil, `{}`), nil
+		}
+
+		_, err := client.CreateImageVariation(ctx, ImageVariationRequest{Image: bytes.NewBufferString("image data")})
+		assert.NoError(t, err)
+		assert.Len(t, bodies, 2)
+		assert.Equal(t, bodies[0], bodies[1])
+		assert.Contains(t, bodies[0], "image data")
+	})
+
+	t.Run("retries streams only before the first event", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+		rt.RoundTripReturnsOnCall(0, &http.Response{StatusCode: 200, Body: ioutil.NopCloser(&truncatedReader{read: true})}, nil)
+		rt.RoundTripReturnsOnCall(1, statusResponse(200, nil, "data: {\"id\":\"1\"}\n\ndata: [DONE]\n\n"), nil)
+
+		var ids []string
+		err := client.CompletionStream(ctx, CompletionRequest{}, func(rsp *CompletionResponse) {
+			ids = append(ids, rsp.ID)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, ids)
+		assert.Equal(t, 2, rt.RoundTripCallCount())
+
+		rt.RoundTripReturnsOnCall(2, &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(&truncatedReader{data: "data: {\"id\":\"2\"}\n\n"}),
+		}, nil)
+		ids = nil
+		err = client.CompletionStream(ctx, CompletionRequest{}, func(rsp *CompletionResponse) {
+			ids = append(ids, rsp.ID)
+		})
+		assert.EqualError(t, err, "connection reset by peer")
+		assert.Equal(t, []string{"2"}, ids)
+		assert.Equal(t, 3, rt.RoundTripCallCount())
+	})
+
+	t.Run("never retries an exceeded quota", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		custom := policy
+		custom.RetryableStatusCodes = []int{http.StatusTooManyRequests}
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(custom))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			return statusResponse(429, nil, `{"error":{"type":"insufficient_quota","code":"insufficient_quota","message":"You exceeded your current quota"}}`), nil
+		}
+
+		_, err := client.Engines(ctx)
+		assert.True(t, errors.Is(err, ErrQuotaExceeded))
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+	})
+
+	t.Run("caps Retry-After at the max delay", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(policy))
+		rt.RoundTripReturnsOnCall(0, statusResponse(429, http.Header{"Retry-After": []string{"86400"}}, "slow down"), nil)
+		rt.RoundTripReturnsOnCall(1, statusResponse(200, nil, `{"data":[]}`), nil)
+
+		start := time.Now()
+		_, err := client.Engines(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rt.RoundTripCallCount())
+		assert.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Hour,
+		}))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			return statusResponse(503, nil, "unavailable"), nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := client.Engines(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 8*time.Second, policy.backoff(4))
+	assert.Equal(t, 10*time.Second, policy.backoff(5))
+	assert.Equal(t, 10*time.Second, policy.backoff(100))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(2)
+		assert.True(t, delay > time.Second && delay <= 2*time.Second, delay)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	delay, ok := retryAfter(statusResponse(429, http.Header{"Retry-After": []string{"3"}}, ""))
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay, ok = retryAfter(statusResponse(429, http.Header{"Retry-After": []string{date}}, ""))
+	assert.True(t, ok)
+	assert.True(t, delay > 58*time.Second && delay <= time.Minute, delay)
+
+	_, ok = retryAfter(statusResponse(429, nil, ""))
+	assert.False(t, ok)
+}
+
+// truncatedReader returns its data and then fails as if the connection was reset
+type truncatedReader struct {
+	data string
+	read bool
+}
+
+func (r *truncatedReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, syscall.ECONNRESET
+	}
+	r.read = true
+	return copy(p, r.data), nil
+}
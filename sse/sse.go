@@ -0,0 +1,213 @@
+// Package sse decodes server-sent event streams, following the event stream interpretation of the WHATWG
+// HTML standard: https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+//
+// Lines may end with CRLF, LF or CR. Comments are ignored, the data fields of an event are joined with
+// newlines, and an event is dispatched by a blank line. An event that isn't terminated by a blank line
+// when the stream ends is discarded.
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultEventType is the type of the events of a stream that don't set an event field
+const DefaultEventType = "message"
+
+var bom = []byte("\xEF\xBB\xBF")
+
+// Event is an event dispatched by an event stream
+type Event struct {
+	// Type is the event field of the event, or DefaultEventType
+	Type string
+	// Data is the value of the data fields of the event, joined with newlines
+	Data string
+	// ID is the last event id of the stream when the event was dispatched
+	ID string
+}
+
+// Decoder decodes the event stream written to it, and calls a handler with every dispatched event. It
+// decodes streams incrementally, so they can be decoded while they are copied or teed.
+type Decoder struct {
+	onEvent func(Event)
+
+	started bool
+	// whether the last line ended with a CR, so an LF that follows is part of the line ending
+	skipLF bool
+	line   []byte
+
+	eventType   string
+	data        []byte
+	lastEventID string
+	retry       time.Duration
+}
+
+// NewDecoder returns a decoder calling onEvent with every dispatched event
+func NewDecoder(onEvent func(Event)) *Decoder {
+	return &Decoder{onEvent: onEvent}
+}
+
+// Write decodes the next bytes of the stream. It never fails.
+func (d *Decoder) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if d.skipLF {
+			d.skipLF = false
+			if p[0] == '\n' {
+				p = p[1:]
+				continue
+			}
+		}
+		i := bytes.IndexAny(p, "\r\n")
+		if i < 0 {
+			d.line = append(d.line, p...)
+			break
+		}
+		d.line = append(d.line, p[:i]...)
+		d.skipLF = p[i] == '\r'
+		p = p[i+1:]
+		d.processLine(d.line)
+		d.line = d.line[:0]
+	}
+	return n, nil
+}
+
+// LastEventID returns the last event id set by the stream
+func (d *Decoder) LastEventID() string {
+	return d.lastEventID
+}
+
+// Retry returns the reconnection time set by the stream, or 0 when the stream didn't set one
+func (d *Decoder) Retry() time.Duration {
+	return d.retry
+}
+
+func (d *Decoder) processLine(line []byte) {
+	if !d.started {
+		d.started = true
+		line = bytes.TrimPrefix(line, bom)
+	}
+	if len(line) == 0 {
+		d.dispatch()
+		return
+	}
+	// lines starting with a colon are comments, which are usually sent to keep the connection alive
+	if line[0] == ':' {
+		return
+	}
+
+	field, value := line, []byte(nil)
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field, value = line[:i], line[i+1:]
+		if len(value) > 0 && value[0] == ' ' {
+			value = value[1:]
+		}
+	}
+	switch string(field) {
+	case "event":
+		d.eventType = text(value)
+	case "data":
+		d.data = append(d.data, value...)
+		d.data = append(d.data, '\n')
+	case "id":
+		if bytes.IndexByte(value, 0) < 0 {
+			d.lastEventID = text(value)
+		}
+	case "retry":
+		if isDigits(value) {
+			if ms, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+				d.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+func (d *Decoder) dispatch() {
+	if len(d.data) == 0 {
+		d.eventType = ""
+		return
+	}
+	event := Event{
+		Type: d.eventType,
+		Data: text(d.data[:len(d.data)-1]),
+		ID:   d.lastEventID,
+	}
+	if event.Type == "" {
+		event.Type = DefaultEventType
+	}
+	d.eventType = ""
+	d.data = d.data[:0]
+	if d.onEvent != nil {
+		d.onEvent(event)
+	}
+}
+
+// text decodes the bytes of a field as UTF-8, replacing invalid sequences
+func text(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return strings.ToValidUTF8(string(b), "\uFFFD")
+}
+
+func isDigits(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader reads the events of an event stream
+type Reader struct {
+	r       io.Reader
+	decoder *Decoder
+	buf     []byte
+	events  []Event
+	err     error
+}
+
+// NewReader returns a reader of the events of the stream read from r
+func NewReader(r io.Reader) *Reader {
+	reader := &Reader{r: r, buf: make([]byte, 4096)}
+	reader.decoder = NewDecoder(func(event Event) {
+		reader.events = append(reader.events, event)
+	})
+	return reader
+}
+
+// Next returns the next event of the stream. It returns io.EOF when the stream ends, or the error
+// returned by the underlying reader.
+func (r *Reader) Next() (Event, error) {
+	for len(r.events) == 0 {
+		if r.err != nil {
+			return Event{}, r.err
+		}
+		n, err := r.r.Read(r.buf)
+		r.decoder.Write(r.buf[:n])
+		r.err = err
+	}
+	event := r.events[0]
+	r.events[0] = Event{}
+	r.events = r.events[1:]
+	return event, nil
+}
+
+// LastEventID returns the last event id set by the stream, which is sent in the Last-Event-ID header
+// when reconnecting
+func (r *Reader) LastEventID() string {
+	return r.decoder.LastEventID()
+}
+
+// Retry returns the reconnection time set by the stream, or 0 when the stream didn't set one
+func (r *Reader) Retry() time.Duration {
+	return r.decoder.Retry()
+}
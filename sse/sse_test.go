@@ -0,0 +1,142 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll reads all the events of the stream
+func readAll(t *testing.T, r io.Reader) []Event {
+	reader := NewReader(r)
+	var events []Event
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return events
+		}
+		if !assert.NoError(t, err) {
+			return events
+		}
+		events = append(events, event)
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stream string
+		events []Event
+	}{
+		{
+			name:   "data fields",
+			stream: "data: first\n\ndata:second\n\n",
+			events: []Event{{Type: "message", Data: "first"}, {Type: "message", Data: "second"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: YHOO\ndata: +2\ndata\ndata:  10\n\n",
+			events: []Event{{Type: "message", Data: "YHOO\n+2\n\n 10"}},
+		},
+		{
+			name:   "line endings",
+			stream: "data: crlf\r\n\r\ndata: cr\r\rdata: mixed\r\ndata: lf\n\n",
+			events: []Event{{Type: "message", Data: "crlf"}, {Type: "message", Data: "cr"}, {Type: "message", Data: "mixed\nlf"}},
+		},
+		{
+			name:   "comments and unknown fields",
+			stream: ": keepalive\n\n:\ndata: value\nunknown: field\n: comment\n\n",
+			events: []Event{{Type: "message", Data: "value"}},
+		},
+		{
+			name:   "event types and ids",
+			stream: "event: add\nid: 1\ndata: 73857293\n\nevent: remove\ndata: 2153\n\nid\ndata: 113411\n\n",
+			events: []Event{
+				{Type: "add", Data: "73857293", ID: "1"},
+				{Type: "remove", Data: "2153", ID: "1"},
+				{Type: "message", Data: "113411"},
+			},
+		},
+		{
+			name:   "ids with null are ignored",
+			stream: "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			events: []Event{{Type: "message", Data: "a", ID: "1"}, {Type: "message", Data: "b", ID: "1"}},
+		},
+		{
+			name:   "events without data aren't dispatched",
+			stream: "event: ping\n\nid: 5\n\ndata\n\n",
+			events: []Event{{Type: "message", Data: "", ID: "5"}},
+		},
+		{
+			name:   "byte order mark",
+			stream: "\xEF\xBB\xBFdata: bom\n\n\xEF\xBB\xBFdata: not a bom\n\n",
+			events: []Event{{Type: "message", Data: "bom"}},
+		},
+		{
+			name:   "invalid utf-8",
+			stream: "data: a\xffb\n\n",
+			events: []Event{{Type: "message", Data: "a\uFFFDb"}},
+		},
+		{
+			name:   "unterminated event",
+			stream: "data: complete\n\ndata: incomplete\n",
+			events: []Event{{Type: "message", Data: "complete"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.events, readAll(t, strings.NewReader(tc.stream)))
+			// the events don't depend on how the stream is split
+			assert.Equal(t, tc.events, readAll(t, iotest.OneByteReader(strings.NewReader(tc.stream))))
+		})
+	}
+}
+
+func TestReaderFields(t *testing.T) {
+	reader := NewReader(strings.NewReader("retry: 1500\nid: 42\ndata: a\n\nretry: soon\nretry: 15s\nid: 43\n\n"))
+	event, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "42", event.ID)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1500*time.Millisecond, reader.Retry())
+	assert.Equal(t, "43", reader.LastEventID())
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestReaderErrors(t *testing.T) {
+	failure := errors.New("connection reset")
+	reader := NewReader(io.MultiReader(strings.NewReader("data: a\n\ndata: b\n"), errReader{failure}))
+	event, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", event.Data)
+	_, err = reader.Next()
+	assert.Equal(t, failure, err)
+	_, err = reader.Next()
+	assert.Equal(t, failure, err)
+}
+
+func TestDecoder(t *testing.T) {
+	var events []Event
+	decoder := NewDecoder(func(event Event) {
+		events = append(events, event)
+	})
+	for _, chunk := range []string{"da", "ta: hel", "lo\r", "\n\r", "\ndata: world\r", "\r"} {
+		n, err := decoder.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, []Event{{Type: "message", Data: "hello"}, {Type: "message", Data: "world"}}, events)
+}
@@ -0,0 +1,220 @@
+package gpt3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/alexandrubordei/go-gpt3/sse"
+)
+
+// the data of the event terminating the streams of the API
+const doneData = "[DONE]"
+
+// streamReader reads the data of the events of a streamed response
+type streamReader struct {
+	events *sse.Reader
+	resp   *http.Response
+}
+
+func newStreamReader(resp *http.Response) *streamReader {
+	return &streamReader{events: sse.NewReader(resp.Body), resp: resp}
+}
+
+// next returns the data of the next event. It returns io.EOF once the stream is terminated by [DONE],
+// ErrStreamTruncated when the body ends before, and an APIError when the API sends an error in the
+// stream.
+func (r *streamReader) next() ([]byte, error) {
+	for {
+		event, err := r.events.Next()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrStreamTruncated
+		}
+		if err != nil {
+			return nil, &TransportError{Err: err}
+		}
+		data := []byte(event.Data)
+		if event.Type == "error" || bytes.Contains(data, []byte(`"error"`)) {
+			if apiErr, ok := r.streamError(data); ok {
+				return nil, apiErr
+			}
+		}
+		// the streaming APIs only send message events, others like keepalives are skipped
+		if event.Type != sse.DefaultEventType {
+			continue
+		}
+		// the stream is completed when terminated by [DONE]
+		if strings.TrimSpace(event.Data) == doneData {
+			return nil, io.EOF
+		}
+		return data, nil
+	}
+}
+
+// streamError decodes an error sent in the stream after the response started successfully. The status of
+// the response is always 200 by then, so the status code of the error is derived from its type, which
+// lets errors.Is match it against the sentinel errors like ErrServerError.
+func (r *streamReader) streamError(data []byte) (APIError, bool) {
+	var result struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.Error == nil {
+		return APIError{}, false
+	}
+	result.Error.StatusCode = streamErrorStatus(*result.Error)
+	result.Error.RequestID = r.resp.Header.Get("X-Request-Id")
+	result.Error.Body = string(data)
+	return *result.Error, true
+}
+
+// streamErrorStatus returns the status code the API responds with for the type of an error, or 0 when the
+// type is unknown
+func streamErrorStatus(apiErr APIError) int {
+	switch {
+	case apiErr.Type == "server_error":
+		return http.StatusInternalServerError
+	case apiErr.Code == "rate_limit_exceeded", apiErr.Type == "requests", apiErr.Type == "tokens",
+		apiErr.isQuotaExceeded():
+		return http.StatusTooManyRequests
+	case apiErr.Type == "invalid_request_error":
+		return http.StatusBadRequest
+	default:
+		return 0
+	}
+}
+
+// Stream is a streamed completion created with CreateCompletionStream. The chunks of the completion are
+// received with Recv until it returns io.EOF, after which Response returns the whole completion.
+type Stream struct {
+	reader *streamReader
+	body   io.Closer
+	cancel context.CancelFunc
+	// the number of choices of each prompt
+	n int
+
+	closeOnce sync.Once
+	closeErr  error
+
+	mu          sync.Mutex
+	err         error
+	accumulator StreamAccumulator
+}
+
+func newStream(resp *http.Response, cancel context.CancelFunc, n int) *Stream {
+	return &Stream{
+		reader: newStreamReader(resp),
+		body:   resp.Body,
+		cancel: cancel,
+		n:      n,
+	}
+}
+
+// Recv returns the next chunk of the completion. It returns io.EOF once the API terminated the stream,
+// and ErrStreamTruncated when the stream ended before. The stream is closed once Recv returns an error.
+// Recv must not be called concurrently, but Close may be called while Recv is blocked.
+func (s *Stream) Recv() (*CompletionResponse, error) {
+	if err := s.failure(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.reader.next()
+	var chunk *CompletionResponse
+	if err == nil {
+		chunk = new(CompletionResponse)
+		if jsonErr := json.Unmarshal(data, chunk); jsonErr != nil {
+			err = fmt.Errorf("invalid json stream data: %v", jsonErr)
+		}
+	}
+
+	s.mu.Lock()
+	if s.err == nil && err != nil {
+		s.err = err
+	}
+	if s.err != nil {
+		err = s.err
+		s.mu.Unlock()
+		s.release()
+		return nil, err
+	}
+	setPromptIndexes(chunk.Choices, s.n)
+	s.accumulator.Add(chunk)
+	s.mu.Unlock()
+	return chunk, nil
+}
+
+// Response returns the completion accumulated from the chunks received so far, see StreamAccumulator
+func (s *Stream) Response() *CompletionResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accumulator.Response()
+}
+
+// Close closes the stream and cancels its request. Closing a stream more than once has no effect.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = ErrStreamClosed
+	}
+	s.mu.Unlock()
+	return s.release()
+}
+
+func (s *Stream) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// release closes the body of the stream and cancels its request
+func (s *Stream) release() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.body.Close()
+		s.cancel()
+	})
+	return s.closeErr
+}
+
+// choiceReader reads the text of a choice of a stream
+type choiceReader struct {
+	stream *Stream
+	index  int
+	text   []byte
+	err    error
+}
+
+// NewChoiceReader returns a reader of the text of the choice with the index as it is received from the
+// stream. The reader returns io.EOF once the stream ends, and closing it closes the stream, which
+// cancels its request. The reader consumes the stream, whose Response is still accumulated.
+func NewChoiceReader(stream *Stream, index int) io.ReadCloser {
+	return &choiceReader{stream: stream, index: index}
+}
+
+func (r *choiceReader) Read(p []byte) (int, error) {
+	for len(r.text) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			r.err = err
+			continue
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index == r.index {
+				r.text = append(r.text, choice.Text...)
+			}
+		}
+	}
+	n := copy(p, r.text)
+	r.text = r.text[n:]
+	return n, nil
+}
+
+func (r *choiceReader) Close() error {
+	return r.stream.Close()
+}
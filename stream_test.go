@@ -0,0 +1,250 @@
+package gpt3
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	chunks := "data: {\"id\":\"cmpl-1\",\"model\":\"text-davinci-003\",\"choices\":[{\"text\":\"Hel\",\"index\":0}]}\n\n" +
+		"data: {\"id\":\"cmpl-1\",\"model\":\"text-davinci-003\",\"choices\":[{\"text\":\"Bon\",\"index\":1}]}\n\n" +
+		"data: {\"id\":\"cmpl-1\",\"model\":\"text-davinci-003\",\"choices\":[{\"text\":\"lo\",\"index\":0,\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: {\"id\":\"cmpl-1\",\"model\":\"text-davinci-003\",\"choices\":[{\"text\":\"jour\",\"index\":1,\"finish_reason\":\"length\"}]}\n\n"
+
+	t.Run("receives chunks until the end of the stream", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, chunks+"data: [DONE]\n\n"), nil)
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptStrings("hello", "bonjour")})
+		assert.NoError(t, err)
+		defer stream.Close()
+
+		var texts []string
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			texts = append(texts, chunk.Choices[0].Text)
+		}
+		assert.Equal(t, []string{"Hel", "Bon", "lo", "jour"}, texts)
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+
+		assert.Equal(t, &CompletionResponse{
+			ID:    "cmpl-1",
+			Model: "text-davinci-003",
+			Choices: []CompletionResponseChoice{
+				{Text: "Hello", Index: 0, FinishReason: "stop"},
+				{Text: "Bonjour", Index: 1, PromptIndex: 1, FinishReason: "length"},
+			},
+		}, stream.Response())
+		assert.NoError(t, stream.Close())
+	})
+
+	t.Run("detects truncated streams", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+			return statusResponse(200, nil, chunks), nil
+		}
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			_, err = stream.Recv()
+			assert.NoError(t, err)
+		}
+		_, err = stream.Recv()
+		assert.Equal(t, ErrStreamTruncated, err)
+		assert.Equal(t, "Hello", stream.Response().Choices[0].Text)
+
+		err = client.CompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")}, func(*CompletionResponse) {})
+		assert.True(t, errors.Is(err, ErrStreamTruncated))
+		err = client.ChatCompletionStream(ctx, ChatCompletionRequest{}, func(*ChatCompletionStreamResponse) {})
+		assert.True(t, errors.Is(err, ErrStreamTruncated))
+		assert.False(t, IsRetryable(err))
+	})
+
+	t.Run("closing cancels the request", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		body, writer := io.Pipe()
+		rt.RoundTripReturns(&http.Response{StatusCode: 200, Header: http.Header{}, Body: body}, nil)
+		go writer.Write([]byte("data: {\"choices\":[{\"text\":\"Hel\"}]}\n\n"))
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.NoError(t, err)
+
+		time.AfterFunc(10*time.Millisecond, func() { stream.Close() })
+		_, err = stream.Recv()
+		assert.Equal(t, ErrStreamClosed, err)
+		assert.Equal(t, context.Canceled, rt.RoundTripArgsForCall(0).Context().Err())
+		_, err = writer.Write([]byte("data: [DONE]\n\n"))
+		assert.Equal(t, io.ErrClosedPipe, err)
+		assert.NoError(t, stream.Close())
+	})
+
+	t.Run("reads any event stream", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, ": keepalive\r\n\r\n"+
+			"data:{\"choices\":[{\"text\":\"Hel\"}]}\r\n\r\n"+
+			"event: ping\r\ndata: {}\r\n\r\n"+
+			"data: {\"choices\":\r\ndata: [{\"text\":\"lo\"}]}\r\n\r\n"+
+			"data: [DONE]\r\n\r\n"), nil)
+
+		var texts []string
+		err := client.CompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")}, func(rsp *CompletionResponse) {
+			texts = append(texts, rsp.Choices[0].Text)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hel", "lo"}, texts)
+	})
+
+	t.Run("returns errors sent in the stream", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, http.Header{"X-Request-Id": []string{"req-123"}},
+			"data: {\"choices\":[{\"text\":\"Hel\"}]}\n\n"+
+				"data: {\"error\":{\"message\":\"The server had an error\",\"type\":\"server_error\"}}\n\n"), nil)
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		var apiErr APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "The server had an error", apiErr.Message)
+		assert.Equal(t, "server_error", apiErr.Type)
+		assert.Equal(t, "req-123", apiErr.RequestID)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.True(t, errors.Is(err, ErrServerError))
+		assert.True(t, IsRetryable(err))
+
+		rt.RoundTripReturns(statusResponse(200, nil, "event: error\ndata: {\"error\":{\"message\":\"overloaded\"}}\n\n"), nil)
+		err = client.ChatCompletionStream(ctx, ChatCompletionRequest{}, func(*ChatCompletionStreamResponse) {})
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "overloaded", apiErr.Message)
+		assert.Equal(t, 0, apiErr.StatusCode)
+		assert.False(t, errors.Is(err, ErrServerError))
+
+		rt.RoundTripReturns(statusResponse(200, nil, "data: {\"error\":{\"message\":\"Rate limit reached\",\"type\":\"requests\",\"code\":\"rate_limit_exceeded\"}}\n\n"), nil)
+		err = client.CompletionStream(ctx, CompletionRequest{}, func(*CompletionResponse) {})
+		assert.True(t, errors.Is(err, ErrRateLimited))
+		assert.True(t, IsRetryable(err))
+
+		rt.RoundTripReturns(statusResponse(200, nil, "data: {\"error\":{\"message\":\"You exceeded your current quota\",\"type\":\"insufficient_quota\"}}\n\n"), nil)
+		err = client.CompletionStream(ctx, CompletionRequest{}, func(*CompletionResponse) {})
+		assert.True(t, errors.Is(err, ErrQuotaExceeded))
+		assert.False(t, errors.Is(err, ErrRateLimited))
+		assert.False(t, IsRetryable(err))
+	})
+
+	t.Run("returns request errors", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithRetryPolicy(RetryPolicy{}))
+		rt.RoundTripReturns(statusResponse(400, nil, `{"error":{"message":"bad request","type":"invalid_request_error"}}`), nil)
+
+		stream, err := client.CreateCompletionStreamWithEngine(ctx, TextDavinci001Engine, CompletionRequest{Prompt: PromptString("hello")})
+		assert.Nil(t, stream)
+		assert.EqualError(t, err, "[400:invalid_request_error] bad request")
+		assert.Equal(t, "/v1/engines/text-davinci-001/completions", rt.RoundTripArgsForCall(0).URL.Path)
+	})
+}
+
+func TestCompletionStreamReader(t *testing.T) {
+	ctx := context.Background()
+	chunks := "data: {\"choices\":[{\"text\":\"first\",\"index\":0}]}\n\n" +
+		"data: {\"choices\":[{\"text\":\"premier\",\"index\":1}]}\n\n" +
+		"data: {\"choices\":[{\"text\":\" line\\nsecond\",\"index\":0}]}\n\n" +
+		"data: {\"choices\":[{\"text\":\" ligne\",\"index\":1}]}\n\n" +
+		"data: {\"choices\":[{\"text\":\" line\",\"index\":0}]}\n\n"
+
+	t.Run("reads the text of the first choice", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, chunks+"data: [DONE]\n\n"), nil)
+
+		reader, err := client.CompletionStreamReader(ctx, CompletionRequest{Prompt: PromptString("hello"), N: IntPtr(2)})
+		assert.NoError(t, err)
+		defer reader.Close()
+
+		var lines []string
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		assert.NoError(t, scanner.Err())
+		assert.Equal(t, []string{"first line", "second line"}, lines)
+	})
+
+	t.Run("reads a selected choice", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, chunks+"data: [DONE]\n\n"), nil)
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello"), N: IntPtr(2)})
+		assert.NoError(t, err)
+		reader := NewChoiceReader(stream, 1)
+		defer reader.Close()
+
+		var text bytes.Buffer
+		_, err = io.Copy(&text, reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "premier ligne", text.String())
+		assert.Equal(t, "first line\nsecond line", stream.Response().Choices[0].Text)
+	})
+
+	t.Run("returns stream errors", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripReturns(statusResponse(200, nil, chunks), nil)
+
+		reader, err := client.CompletionStreamReader(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		defer reader.Close()
+
+		text, err := ioutil.ReadAll(reader)
+		assert.Equal(t, ErrStreamTruncated, err)
+		assert.Equal(t, "first line\nsecond line", string(text))
+	})
+
+	t.Run("closing cancels the request", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		body, writer := io.Pipe()
+		rt.RoundTripReturns(&http.Response{StatusCode: 200, Header: http.Header{}, Body: body}, nil)
+		go writer.Write([]byte("data: {\"choices\":[{\"text\":\"Hel\"}]}\n\n"))
+
+		reader, err := client.CompletionStreamReader(ctx, CompletionRequest{Model: "text-davinci-003", Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		assert.Equal(t, "/v1/completions", rt.RoundTripArgsForCall(0).URL.Path)
+		p := make([]byte, 10)
+		n, err := reader.Read(p)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hel", string(p[:n]))
+
+		assert.NoError(t, reader.Close())
+		assert.Equal(t, context.Canceled, rt.RoundTripArgsForCall(0).Context().Err())
+		_, err = reader.Read(p)
+		assert.Equal(t, ErrStreamClosed, err)
+	})
+}
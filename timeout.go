@@ -0,0 +1,254 @@
+package gpt3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// the idle timeout of streams when none is set with WithIdleTimeout
+const defaultIdleTimeout = defaultTimeoutSeconds * time.Second
+
+// ConnectTimeoutError is returned when the connection of a request wasn't established within the timeout
+// set with WithConnectTimeout
+type ConnectTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *ConnectTimeoutError) Error() string {
+	return fmt.Sprintf("no connection after the connect timeout of %s", e.Timeout)
+}
+
+// FirstEventTimeoutError is returned when the first data of a stream wasn't received within the timeout
+// set with WithFirstEventTimeout
+type FirstEventTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *FirstEventTimeoutError) Error() string {
+	return fmt.Sprintf("no stream data after the first event timeout of %s", e.Timeout)
+}
+
+// IdleTimeoutError is returned when a started stream received no data within the timeout set with
+// WithIdleTimeout
+type IdleTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *IdleTimeoutError) Error() string {
+	return fmt.Sprintf("stream idle for longer than the idle timeout of %s", e.Timeout)
+}
+
+type noStreamTimeoutsKey struct{}
+
+type noClientTimeoutKey struct{}
+
+// withoutClientTimeout returns a context whose requests are exempt from the timeout of the http client,
+// for responses read as a stream of unknown length like the contents of a file
+func withoutClientTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noClientTimeoutKey{}, true)
+}
+
+// withoutStreamTimeouts returns a context whose streams are exempt from the first event and idle
+// timeouts, for streams that are quiet for minutes like the events of a fine-tune job
+func withoutStreamTimeouts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noStreamTimeoutsKey{}, true)
+}
+
+// timeouts are the connect, first event and idle timeouts of the requests of a client
+type timeouts struct {
+	connect    time.Duration
+	firstEvent time.Duration
+	idle       time.Duration
+}
+
+// doWithTimeouts sends the request of a single attempt. The request is cancelled when its connection isn't
+// established within the connect timeout, see connectTimer. The body of streamed responses is cancelled when it
+// doesn't receive data within the first event timeout, and then within the idle timeout between reads.
+// Streams and file downloads are exempt from the timeout of the http client, which would end them while
+// they are healthy.
+func (c *client) doWithTimeouts(req *http.Request, stream bool) (*http.Response, error) {
+	httpClient := c.httpClient
+	noClientTimeout, _ := req.Context().Value(noClientTimeoutKey{}).(bool)
+	if (stream || noClientTimeout) && httpClient.Timeout > 0 {
+		streamClient := *httpClient
+		streamClient.Timeout = 0
+		httpClient = &streamClient
+	}
+	t := c.timeouts
+	if exempt, _ := req.Context().Value(noStreamTimeoutsKey{}).(bool); exempt {
+		t.firstEvent, t.idle = 0, 0
+	}
+	if t.connect <= 0 && (!stream || (t.firstEvent <= 0 && t.idle <= 0)) {
+		return httpClient.Do(req)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(req.Context())
+	var connect *connectTimer
+	if t.connect > 0 {
+		connect = &connectTimer{timeout: t.connect, cancel: cancel}
+		ctx = httptrace.WithClientTrace(ctx, connect.trace())
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if connect != nil && connect.stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, &ConnectTimeoutError{Timeout: t.connect}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	body := &timeoutBody{ReadCloser: resp.Body, cancel: cancel}
+	if stream {
+		body.idle = t.idle
+		if t.firstEvent > 0 {
+			body.firstEvent = t.firstEvent
+			body.firstEventDeadline = start.Add(t.firstEvent)
+		}
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// connectTimer cancels a request whose transport doesn't get a connection within the timeout, which
+// includes dialing and the TLS handshake but not waiting for the response. It relies on the connection
+// events of httptrace, which http.Transport reports, so it has no effect with transports that don't.
+type connectTimer struct {
+	timeout time.Duration
+	cancel  context.CancelFunc
+
+	mu    sync.Mutex
+	timer *time.Timer
+	// the connection attempt, as a request may get several connections when it is redirected
+	attempt  int
+	timedOut bool
+	stopped  bool
+}
+
+func (c *connectTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.stopped {
+				return
+			}
+			c.attempt++
+			attempt := c.attempt
+			c.timer = time.AfterFunc(c.timeout, func() {
+				c.mu.Lock()
+				timedOut := attempt == c.attempt && !c.stopped
+				c.timedOut = c.timedOut || timedOut
+				c.mu.Unlock()
+				if timedOut {
+					c.cancel()
+				}
+			})
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			// a later callback of the stopped timer sees that its attempt is over
+			c.attempt++
+			if c.timer != nil {
+				c.timer.Stop()
+			}
+		},
+	}
+}
+
+// stop stops the timer once the request returned, and returns whether the connection timed out
+func (c *connectTimer) stop() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	return c.timedOut
+}
+
+// timeoutBody cancels the request of a response when a read of its body blocks for longer than the
+// first event or idle timeout, and when the body is closed
+type timeoutBody struct {
+	io.ReadCloser
+	cancel             context.CancelFunc
+	firstEvent         time.Duration
+	firstEventDeadline time.Time
+	idle               time.Duration
+
+	started bool
+	mu      sync.Mutex
+	err     error
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	if err := b.timeoutErr(); err != nil {
+		return 0, err
+	}
+
+	var timeout time.Duration
+	var timeoutErr error
+	if !b.started && b.firstEvent > 0 {
+		timeout = time.Until(b.firstEventDeadline)
+		timeoutErr = &FirstEventTimeoutError{Timeout: b.firstEvent}
+	} else if b.idle > 0 {
+		timeout = b.idle
+		timeoutErr = &IdleTimeoutError{Timeout: b.idle}
+	}
+	if timeoutErr == nil {
+		return b.read(p)
+	}
+	if timeout <= 0 {
+		b.timedOut(timeoutErr)
+		return 0, timeoutErr
+	}
+
+	timer := time.AfterFunc(timeout, func() {
+		b.timedOut(timeoutErr)
+	})
+	n, err := b.read(p)
+	timer.Stop()
+	if timedOut := b.timeoutErr(); timedOut != nil && err != nil {
+		err = timedOut
+	}
+	return n, err
+}
+
+func (b *timeoutBody) read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.started = true
+	}
+	return n, err
+}
+
+func (b *timeoutBody) timedOut(err error) {
+	b.mu.Lock()
+	if b.err == nil {
+		b.err = err
+	}
+	b.mu.Unlock()
+	b.cancel()
+}
+
+func (b *timeoutBody) timeoutErr() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
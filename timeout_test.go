@@ -0,0 +1,201 @@
+package gpt3
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// streamResponse returns a response streaming what is written to the returned writer, which fails once
+// the request is cancelled
+func streamResponse(req *http.Request) (*http.Response, *io.PipeWriter) {
+	body, writer := io.Pipe()
+	go func() {
+		<-req.Context().Done()
+		writer.CloseWithError(req.Context().Err())
+	}()
+	return &http.Response{StatusCode: 200, Header: http.Header{}, Body: body}, writer
+}
+
+// connectSlowly reports getting a connection like http.Transport, but only gets it once the delay passed or
+// the request is cancelled
+func connectSlowly(req *http.Request, delay time.Duration) error {
+	trace := httptrace.ContextClientTrace(req.Context())
+	trace.GetConn(req.URL.Host)
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-time.After(delay):
+		trace.GotConn(httptrace.GotConnInfo{})
+		return nil
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("connect timeout", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithConnectTimeout(10*time.Millisecond))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			return nil, connectSlowly(req, time.Hour)
+		}
+
+		_, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		var timeoutErr *ConnectTimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, 10*time.Millisecond, timeoutErr.Timeout)
+		assert.EqualError(t, err, "no connection after the connect timeout of 10ms")
+		assert.True(t, IsRetryable(err))
+	})
+
+	t.Run("connect timeout doesn't count waiting for the response", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithConnectTimeout(20*time.Millisecond))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			if err := connectSlowly(req, 5*time.Millisecond); err != nil {
+				return nil, err
+			}
+			// the completion is generated before the response headers are sent
+			time.Sleep(60 * time.Millisecond)
+			return jsonResponse(t, &CompletionResponse{ID: "123"}), nil
+		}
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		assert.Equal(t, "123", rsp.ID)
+		assert.Equal(t, 1, rt.RoundTripCallCount())
+	})
+
+	t.Run("connect timeout is retried", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithConnectTimeout(10*time.Millisecond),
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			if rt.RoundTripCallCount() == 1 {
+				return nil, connectSlowly(req, time.Hour)
+			}
+			return jsonResponse(t, &CompletionResponse{ID: "123"}), nil
+		}
+
+		rsp, err := client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		assert.Equal(t, "123", rsp.ID)
+		assert.Equal(t, 2, rt.RoundTripCallCount())
+	})
+
+	t.Run("first event timeout", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithFirstEventTimeout(20*time.Millisecond))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			resp, _ := streamResponse(req)
+			return resp, nil
+		}
+
+		_, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		var timeoutErr *FirstEventTimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.EqualError(t, err, "no stream data after the first event timeout of 20ms")
+		assert.Equal(t, context.Canceled, rt.RoundTripArgsForCall(0).Context().Err())
+	})
+
+	t.Run("idle timeout", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithIdleTimeout(20*time.Millisecond))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			resp, writer := streamResponse(req)
+			go writer.Write([]byte("data: {\"choices\":[{\"text\":\"Hel\"}]}\n\n"))
+			return resp, nil
+		}
+
+		stream, err := client.CreateCompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")})
+		assert.NoError(t, err)
+		defer stream.Close()
+		_, err = stream.Recv()
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		var timeoutErr *IdleTimeoutError
+		assert.True(t, errors.As(err, &timeoutErr))
+		assert.EqualError(t, err, "stream idle for longer than the idle timeout of 20ms")
+		assert.False(t, IsRetryable(err))
+	})
+
+	t.Run("fine-tune events are exempt from the stream timeouts", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithFirstEventTimeout(20*time.Millisecond),
+			WithIdleTimeout(20*time.Millisecond))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			resp, writer := streamResponse(req)
+			go func() {
+				time.Sleep(60 * time.Millisecond)
+				writer.Write([]byte("data: {\"message\":\"Fine-tune started\"}\n\n"))
+				time.Sleep(60 * time.Millisecond)
+				writer.Write([]byte("data: {\"message\":\"Fine-tune succeeded\"}\n\ndata: [DONE]\n\n"))
+			}()
+			return resp, nil
+		}
+
+		var messages []string
+		err := client.StreamFineTuneEvents(ctx, "ft-123", func(event *Event) {
+			messages = append(messages, event.Message)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Fine-tune started", "Fine-tune succeeded"}, messages)
+	})
+
+	t.Run("file downloads are exempt from the client timeout", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		httpClient.Timeout = 20 * time.Millisecond
+		client := NewClient("test-key", WithHTTPClient(httpClient))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			resp, writer := streamResponse(req)
+			go func() {
+				for i := 0; i < 5; i++ {
+					writer.Write([]byte(`{"prompt":"a","completion":"b"}` + "\n"))
+					time.Sleep(10 * time.Millisecond)
+				}
+				writer.Close()
+			}()
+			return resp, nil
+		}
+
+		content, err := client.DownloadFileContent(ctx, "file-123")
+		assert.NoError(t, err)
+		defer content.Close()
+		data, err := ioutil.ReadAll(content)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, strings.Count(string(data), "\n"))
+	})
+
+	t.Run("streams are exempt from the client timeout", func(t *testing.T) {
+		rt, httpClient := fakeHttpClient()
+		httpClient.Timeout = 20 * time.Millisecond
+		client := NewClient("test-key", WithHTTPClient(httpClient), WithIdleTimeout(time.Second))
+		rt.RoundTripStub = func(req *http.Request) (*http.Response, error) {
+			resp, writer := streamResponse(req)
+			go func() {
+				for i := 0; i < 5; i++ {
+					writer.Write([]byte("data: {\"choices\":[{\"text\":\"a\"}]}\n\n"))
+					time.Sleep(10 * time.Millisecond)
+				}
+				writer.Write([]byte("data: [DONE]\n\n"))
+			}()
+			return resp, nil
+		}
+
+		var text string
+		err := client.CompletionStream(ctx, CompletionRequest{Prompt: PromptString("hello")}, func(rsp *CompletionResponse) {
+			text += rsp.Choices[0].Text
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "aaaaa", text)
+	})
+}
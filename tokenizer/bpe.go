@@ -0,0 +1,59 @@
+package tokenizer
+
+import "math"
+
+// the rank of parts that can't be merged
+const noRank = math.MaxInt32
+
+// bpePart is a part of a piece being merged. start is where it begins in the piece, and rank is the rank
+// of the part merged with the next one.
+type bpePart struct {
+	start int
+	rank  int
+}
+
+// bytePairEncode appends the tokens of the piece to tokens. Starting from single bytes, the adjacent
+// parts whose concatenation has the lowest rank are merged until no concatenation has a rank.
+func (e *Encoding) bytePairEncode(piece []byte, tokens []int) []int {
+	// the parts end with a sentinel at the end of the piece
+	parts := make([]bpePart, len(piece)+1)
+	for i := range parts {
+		parts[i] = bpePart{start: i, rank: noRank}
+	}
+	// pairRank returns the rank of the ith part merged with the next one
+	pairRank := func(i int) int {
+		if i+2 >= len(parts) {
+			return noRank
+		}
+		if rank, ok := e.ranks[string(piece[parts[i].start:parts[i+2].start])]; ok {
+			return rank
+		}
+		return noRank
+	}
+	for i := 0; i < len(parts)-2; i++ {
+		parts[i].rank = pairRank(i)
+	}
+
+	for len(parts) > 2 {
+		// merge the leftmost pair with the lowest rank
+		lowest := 0
+		for i := 1; i < len(parts)-2; i++ {
+			if parts[i].rank < parts[lowest].rank {
+				lowest = i
+			}
+		}
+		if parts[lowest].rank == noRank {
+			break
+		}
+		parts = append(parts[:lowest+1], parts[lowest+2:]...)
+		parts[lowest].rank = pairRank(lowest)
+		if lowest > 0 {
+			parts[lowest-1].rank = pairRank(lowest - 1)
+		}
+	}
+
+	for i := 0; i < len(parts)-1; i++ {
+		tokens = append(tokens, e.ranks[string(piece[parts[i].start:parts[i+1].start])])
+	}
+	return tokens
+}
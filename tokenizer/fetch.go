@@ -0,0 +1,52 @@
+//go:build ignore
+// +build ignore
+
+// fetch downloads the ranks of the encodings into the data directory, where they are committed and
+// embedded by the tokenizer package. Run it with go generate when updating the ranks.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const baseURL = "https://openaipublic.blob.core.windows.net/encodings/"
+
+var files = []string{
+	"r50k_base.tiktoken",
+	"p50k_base.tiktoken",
+	"cl100k_base.tiktoken",
+}
+
+func main() {
+	for _, file := range files {
+		if err := fetch(file); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func fetch(file string) error {
+	resp, err := http.Get(baseURL + file)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed downloading %s: %s", file, resp.Status)
+	}
+
+	out, err := os.Create(filepath.Join("data", file))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed downloading %s: %w", file, err)
+	}
+	return out.Close()
+}
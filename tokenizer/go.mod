@@ -0,0 +1,8 @@
+module github.com/alexandrubordei/go-gpt3/tokenizer
+
+go 1.16
+
+require (
+	github.com/dlclark/regexp2 v1.10.0
+	github.com/stretchr/testify v1.8.4
+)
@@ -0,0 +1,196 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:generate go run fetch.go
+
+//go:embed data
+var data embed.FS
+
+// ErrVocabularyMissing is returned by GetEncoding when the ranks of the encoding are missing from the
+// data directory
+var ErrVocabularyMissing = errors.New("vocabulary missing")
+
+type encodingSpec struct {
+	file    string
+	pattern string
+	special map[string]int
+	// the number of tokens of the encoding, including the special tokens
+	vocabSize int
+}
+
+var specs = map[string]encodingSpec{
+	R50kBase: {
+		file:      "r50k_base.tiktoken",
+		pattern:   r50kPattern,
+		special:   map[string]int{EndOfText: 50256},
+		vocabSize: 50257,
+	},
+	P50kBase: {
+		file:      "p50k_base.tiktoken",
+		pattern:   r50kPattern,
+		special:   map[string]int{EndOfText: 50256},
+		vocabSize: 50281,
+	},
+	// p50k_edit has the ranks of p50k_base, with the fill in the middle tokens of the edit models
+	P50kEdit: {
+		file:    "p50k_base.tiktoken",
+		pattern: r50kPattern,
+		special: map[string]int{
+			EndOfText: 50256,
+			FimPrefix: 50281,
+			FimMiddle: 50282,
+			FimSuffix: 50283,
+		},
+		vocabSize: 50284,
+	},
+	Cl100kBase: {
+		file:    "cl100k_base.tiktoken",
+		pattern: cl100kPattern,
+		special: map[string]int{
+			EndOfText:   100257,
+			FimPrefix:   100258,
+			FimMiddle:   100259,
+			FimSuffix:   100260,
+			EndOfPrompt: 100276,
+		},
+		vocabSize: 100277,
+	},
+}
+
+type loadedEncoding struct {
+	once     sync.Once
+	encoding *Encoding
+	err      error
+}
+
+var encodings = map[string]*loadedEncoding{
+	R50kBase:   {},
+	P50kBase:   {},
+	P50kEdit:   {},
+	Cl100kBase: {},
+}
+
+// GetEncoding returns the encoding with the given name. The encodings are loaded from the embedded ranks
+// the first time they are used.
+func GetEncoding(name string) (*Encoding, error) {
+	if name == GPT2 {
+		name = R50kBase
+	}
+	loaded, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %s", name)
+	}
+	loaded.once.Do(func() {
+		loaded.encoding, loaded.err = loadEncoding(name, specs[name])
+	})
+	return loaded.encoding, loaded.err
+}
+
+func loadEncoding(name string, spec encodingSpec) (*Encoding, error) {
+	f, err := data.Open("data/" + spec.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrVocabularyMissing, spec.file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranks, err := ParseRanks(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", spec.file, err)
+	}
+	if size := len(ranks) + len(spec.special); size != spec.vocabSize {
+		return nil, fmt.Errorf("invalid %s: expected %d tokens, got %d", spec.file, spec.vocabSize, size)
+	}
+	return NewEncoding(name, ranks, spec.special, spec.pattern)
+}
+
+// ParseRanks parses mergeable ranks in the tiktoken format, a line per token with the base64 encoded
+// bytes of the token and its rank separated by a space
+func ParseRanks(r io.Reader) (map[string]int, error) {
+	ranks := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a token and a rank", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	return ranks, scanner.Err()
+}
+
+// modelPrefixes maps the models, or prefixes of their names, to their encoding
+var modelPrefixes = []struct {
+	prefix   string
+	encoding string
+}{
+	{"gpt-4", Cl100kBase},
+	{"gpt-3.5-turbo", Cl100kBase},
+	{"text-embedding-ada-002", Cl100kBase},
+	{"text-davinci-edit-001", P50kEdit},
+	{"code-davinci-edit-001", P50kEdit},
+	{"text-davinci-003", P50kBase},
+	{"text-davinci-002", P50kBase},
+	{"code-davinci", P50kBase},
+	{"code-cushman", P50kBase},
+	{"davinci-codex", P50kBase},
+	{"cushman-codex", P50kBase},
+	{"text-davinci-001", R50kBase},
+	{"text-curie-001", R50kBase},
+	{"text-babbage-001", R50kBase},
+	{"text-ada-001", R50kBase},
+	{"text-similarity-", R50kBase},
+	{"text-search-", R50kBase},
+	{"code-search-", R50kBase},
+	{"davinci", R50kBase},
+	{"curie", R50kBase},
+	{"babbage", R50kBase},
+	{"ada", R50kBase},
+	{"gpt2", R50kBase},
+}
+
+// EncodingNameForModel returns the name of the encoding of the model, which may be a dated snapshot like
+// gpt-4-0314 or a fine-tuned model like curie:ft-acme-2021-03-03-21-44-20
+func EncodingNameForModel(model string) (string, error) {
+	for _, m := range modelPrefixes {
+		if strings.HasPrefix(model, m.prefix) {
+			return m.encoding, nil
+		}
+	}
+	return "", fmt.Errorf("no encoding for model %s", model)
+}
+
+// ForModel returns the encoding of the model
+func ForModel(model string) (*Encoding, error) {
+	name, err := EncodingNameForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return GetEncoding(name)
+}
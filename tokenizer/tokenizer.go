@@ -0,0 +1,164 @@
+// Package tokenizer counts, encodes and decodes the tokens of the OpenAI models with the byte pair
+// encodings used by the API: r50k_base (GPT-2 and the GPT-3 base models), p50k_base (the codex models,
+// text-davinci-002 and text-davinci-003), p50k_edit (the edit models) and cl100k_base (gpt-3.5-turbo,
+// gpt-4 and text-embedding-ada-002).
+//
+// The ranks of the encodings are embedded from the data directory, see the data/README.md for how they
+// are updated.
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dlclark/regexp2"
+)
+
+// Names of the encodings
+const (
+	R50kBase   = "r50k_base"
+	P50kBase   = "p50k_base"
+	P50kEdit   = "p50k_edit"
+	Cl100kBase = "cl100k_base"
+	// GPT2 is the encoding of GPT-2, which has the same tokens as r50k_base
+	GPT2 = "gpt2"
+)
+
+// Special tokens
+const (
+	EndOfText   = "<|endoftext|>"
+	FimPrefix   = "<|fim_prefix|>"
+	FimMiddle   = "<|fim_middle|>"
+	FimSuffix   = "<|fim_suffix|>"
+	EndOfPrompt = "<|endofprompt|>"
+)
+
+// ErrUnknownToken is returned when decoding a token that isn't part of the encoding
+var ErrUnknownToken = errors.New("unknown token")
+
+// the patterns splitting the text into the pieces that are encoded separately, as used by the API
+const (
+	r50kPattern   = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+	cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+)
+
+// Encoding is a byte pair encoding, which is safe for concurrent use
+type Encoding struct {
+	name           string
+	ranks          map[string]int
+	decoder        map[int]string
+	special        map[string]int
+	specialDecoder map[int]string
+	pattern        *regexp2.Regexp
+	specialPattern *regexp.Regexp
+}
+
+// NewEncoding returns an encoding of the given mergeable ranks, which must contain every single byte,
+// special tokens and pattern splitting the text into pieces. The pattern uses the syntax of
+// github.com/dlclark/regexp2, which supports the lookaheads of the patterns of the OpenAI encodings.
+func NewEncoding(name string, ranks map[string]int, special map[string]int, pattern string) (*Encoding, error) {
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("encoding %s misses the byte %#x", name, b)
+		}
+	}
+	compiled, err := regexp2.Compile(pattern, regexp2.None)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	e := &Encoding{
+		name:           name,
+		ranks:          ranks,
+		decoder:        make(map[int]string, len(ranks)),
+		special:        special,
+		specialDecoder: make(map[int]string, len(special)),
+		pattern:        compiled,
+	}
+	for piece, rank := range ranks {
+		e.decoder[rank] = piece
+	}
+	if len(e.decoder) != len(ranks) {
+		return nil, fmt.Errorf("encoding %s has duplicate ranks", name)
+	}
+
+	var quoted []string
+	for token, rank := range special {
+		e.specialDecoder[rank] = token
+		quoted = append(quoted, regexp.QuoteMeta(token))
+	}
+	if len(quoted) > 0 {
+		e.specialPattern = regexp.MustCompile(strings.Join(quoted, "|"))
+	}
+	return e, nil
+}
+
+// Name returns the name of the encoding
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode returns the tokens of the text. Special tokens in the text are encoded as ordinary text, the
+// same way the API encodes the prompts of completions.
+func (e *Encoding) Encode(text string) []int {
+	return e.encodeOrdinary(text, nil)
+}
+
+// EncodeWithSpecialTokens returns the tokens of the text, encoding the special tokens in the text, like
+// <|endoftext|>, as their own token.
+func (e *Encoding) EncodeWithSpecialTokens(text string) []int {
+	if e.specialPattern == nil {
+		return e.Encode(text)
+	}
+	var tokens []int
+	for {
+		loc := e.specialPattern.FindStringIndex(text)
+		if loc == nil {
+			return e.encodeOrdinary(text, tokens)
+		}
+		tokens = e.encodeOrdinary(text[:loc[0]], tokens)
+		tokens = append(tokens, e.special[text[loc[0]:loc[1]]])
+		text = text[loc[1]:]
+	}
+}
+
+// Count returns the number of tokens of the text, as encoded by Encode
+func (e *Encoding) Count(text string) int {
+	return len(e.Encode(text))
+}
+
+// Decode returns the text of the tokens. The text is not valid UTF-8 when the tokens split a character.
+func (e *Encoding) Decode(tokens []int) (string, error) {
+	var b strings.Builder
+	for _, token := range tokens {
+		if piece, ok := e.decoder[token]; ok {
+			b.WriteString(piece)
+		} else if special, ok := e.specialDecoder[token]; ok {
+			b.WriteString(special)
+		} else {
+			return "", fmt.Errorf("%w: %d", ErrUnknownToken, token)
+		}
+	}
+	return b.String(), nil
+}
+
+// encodeOrdinary appends the tokens of the pieces of the text to tokens
+func (e *Encoding) encodeOrdinary(text string, tokens []int) []int {
+	if text == "" {
+		return tokens
+	}
+	// the pattern can't time out nor fail to match, every character is matched by \s+ or another branch
+	m, _ := e.pattern.FindStringMatch(text)
+	for m != nil {
+		piece := m.String()
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+		} else {
+			tokens = e.bytePairEncode([]byte(piece), tokens)
+		}
+		m, _ = e.pattern.FindNextMatch(m)
+	}
+	return tokens
+}
@@ -0,0 +1,170 @@
+package tokenizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEncoding returns an encoding of all the single bytes, whose rank is their value, and the given merges
+func testEncoding(t *testing.T, merges ...string) *Encoding {
+	ranks := map[string]int{}
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = b
+	}
+	for i, merge := range merges {
+		ranks[merge] = 256 + i
+	}
+	e, err := NewEncoding("test", ranks, map[string]int{EndOfText: 1000}, r50kPattern)
+	assert.NoError(t, err)
+	return e
+}
+
+func TestBytePairEncode(t *testing.T) {
+	e := testEncoding(t, "ab", "bc", "aa", "aaaa", "aaa")
+
+	// the lowest rank is merged first
+	assert.Equal(t, []int{256, 'c'}, e.bytePairEncode([]byte("abc"), nil))
+	assert.Equal(t, []int{'x', 257}, e.bytePairEncode([]byte("xbc"), nil))
+	// leftmost pairs are merged first, aaa has a higher rank than aaaa
+	assert.Equal(t, []int{259}, e.bytePairEncode([]byte("aaaa"), nil))
+	assert.Equal(t, []int{259, 'a'}, e.bytePairEncode([]byte("aaaaa"), nil))
+	assert.Equal(t, []int{'z'}, e.bytePairEncode([]byte("z"), nil))
+}
+
+func TestEncoding(t *testing.T) {
+	e := testEncoding(t, " w", "or", " wor", "ld", " world", "he", "ll", "hell", "hello")
+
+	tokens := e.Encode("hello world")
+	assert.Equal(t, []int{264, 260}, tokens)
+	assert.Equal(t, 2, e.Count("hello world"))
+	text, err := e.Decode(tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", text)
+
+	// special tokens are ordinary text unless allowed
+	assert.Equal(t, len(EndOfText), e.Count(EndOfText))
+	assert.Equal(t, []int{264, 1000, 260}, e.EncodeWithSpecialTokens("hello<|endoftext|> world"))
+	text, err = e.Decode([]int{264, 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello<|endoftext|>", text)
+
+	for _, text := range []string{"", "  leading and trailing  ", "line\nbreaks\r\n\n", "ünïcödé 🎉 文字", "it's 42!"} {
+		decoded, err := e.Decode(e.Encode(text))
+		assert.NoError(t, err)
+		assert.Equal(t, text, decoded)
+	}
+
+	_, err = e.Decode([]int{264, 5000})
+	assert.True(t, errors.Is(err, ErrUnknownToken))
+	assert.EqualError(t, err, "unknown token: 5000")
+}
+
+func TestNewEncoding(t *testing.T) {
+	_, err := NewEncoding("test", map[string]int{"a": 0}, nil, r50kPattern)
+	assert.EqualError(t, err, "encoding test misses the byte 0x0")
+
+	ranks := testEncoding(t).ranks
+	ranks["ab"] = 'a'
+	_, err = NewEncoding("test", ranks, nil, r50kPattern)
+	assert.EqualError(t, err, "encoding test has duplicate ranks")
+}
+
+func TestParseRanks(t *testing.T) {
+	ranks, err := ParseRanks(strings.NewReader("IQ== 0\nIg== 1\n\naGVsbG8= 2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"!": 0, "\"": 1, "hello": 2}, ranks)
+
+	_, err = ParseRanks(strings.NewReader("IQ== 0\nIg==\n"))
+	assert.EqualError(t, err, "line 2: expected a token and a rank")
+	_, err = ParseRanks(strings.NewReader("IQ== zero\n"))
+	assert.Error(t, err)
+}
+
+func TestEncodingNameForModel(t *testing.T) {
+	for model, encoding := range map[string]string{
+		"gpt-4-0314":                        Cl100kBase,
+		"gpt-3.5-turbo":                     Cl100kBase,
+		"text-embedding-ada-002":            Cl100kBase,
+		"text-davinci-003":                  P50kBase,
+		"code-davinci-002":                  P50kBase,
+		"text-davinci-edit-001":             P50kEdit,
+		"code-davinci-edit-001":             P50kEdit,
+		"text-davinci-001":                  R50kBase,
+		"davinci":                           R50kBase,
+		"curie:ft-acme-2021-03-03-21-44-20": R50kBase,
+	} {
+		name, err := EncodingNameForModel(model)
+		assert.NoError(t, err)
+		assert.Equal(t, encoding, name, model)
+	}
+
+	_, err := EncodingNameForModel("whisper-1")
+	assert.EqualError(t, err, "no encoding for model whisper-1")
+	_, err = GetEncoding("o200k_base")
+	assert.EqualError(t, err, "unknown encoding o200k_base")
+}
+
+// getEncoding returns the embedded encoding
+func getEncoding(tb testing.TB, name string) *Encoding {
+	e, err := GetEncoding(name)
+	if !assert.NoError(tb, err) {
+		tb.FailNow()
+	}
+	return e
+}
+
+// golden vectors of the tokens counted by the API
+var goldenVectors = map[string][]struct {
+	text   string
+	tokens []int
+}{
+	R50kBase: {
+		{"hello world", []int{31373, 995}},
+		{"Hello, world!", []int{15496, 11, 995, 0}},
+		{"tiktoken is great!", []int{83, 1134, 30001, 318, 1049, 0}},
+	},
+	P50kBase: {
+		{"hello world", []int{31373, 995}},
+		{"Hello, world!", []int{15496, 11, 995, 0}},
+	},
+	P50kEdit: {
+		{"hello world", []int{31373, 995}},
+		{"Hello, world!", []int{15496, 11, 995, 0}},
+	},
+	Cl100kBase: {
+		{"hello world", []int{15339, 1917}},
+		{"Hello, world!", []int{9906, 11, 1917, 0}},
+		{"tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+	},
+}
+
+func TestGoldenVectors(t *testing.T) {
+	for name, vectors := range goldenVectors {
+		name, vectors := name, vectors
+		t.Run(name, func(t *testing.T) {
+			e := getEncoding(t, name)
+			for _, v := range vectors {
+				assert.Equal(t, v.tokens, e.Encode(v.text), v.text)
+				text, err := e.Decode(v.tokens)
+				assert.NoError(t, err)
+				assert.Equal(t, v.text, text)
+			}
+			for special, token := range specs[name].special {
+				assert.Equal(t, []int{token}, e.EncodeWithSpecialTokens(special), special)
+			}
+		})
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	e := getEncoding(b, Cl100kBase)
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog, 1234567890 times! ", 50)
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Encode(text)
+	}
+}
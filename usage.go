@@ -0,0 +1,199 @@
+package gpt3
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the number of windows a UsageTracker keeps by default
+const defaultUsageWindows = 24
+
+// UsageTrackerOptions configures a UsageTracker
+type UsageTrackerOptions struct {
+	// Prices are used to estimate the cost of the usage. Defaults to DefaultPriceTable.
+	Prices PriceTable
+	// Window is the length of the time windows the usage is totaled by, like time.Hour. Zero disables
+	// the windows.
+	Window time.Duration
+	// Windows is the number of most recent windows kept. Defaults to 24.
+	Windows int
+}
+
+// UsageTotals is the total usage of a number of requests
+type UsageTotals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (u *UsageTotals) add(usage UsageTotals) {
+	u.Requests += usage.Requests
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+	u.TotalTokens += usage.TotalTokens
+	u.Cost += usage.Cost
+}
+
+// UsageWindow is the total usage of the requests made in the window starting at Start
+type UsageWindow struct {
+	Start time.Time `json:"start"`
+	UsageTotals
+}
+
+// UsageSnapshot is the usage recorded by a UsageTracker at a point in time
+type UsageSnapshot struct {
+	Total  UsageTotals            `json:"total"`
+	Models map[string]UsageTotals `json:"models"`
+	Tags   map[string]UsageTotals `json:"tags"`
+	// Windows are sorted from the oldest to the most recent
+	Windows []UsageWindow `json:"windows,omitempty"`
+}
+
+// UsageTracker totals the tokens used by the requests of the clients it is added to with WithUsageTracker,
+// per model, per tag and per time window, and estimates their cost in dollars. Streamed responses don't
+// report their usage, they are only counted as requests. A UsageTracker is safe for concurrent use.
+type UsageTracker struct {
+	mu      sync.Mutex
+	options UsageTrackerOptions
+	now     func() time.Time
+
+	total   UsageTotals
+	models  map[string]*UsageTotals
+	tags    map[string]*UsageTotals
+	windows []*UsageWindow
+}
+
+// NewUsageTracker returns a new UsageTracker
+func NewUsageTracker(options UsageTrackerOptions) *UsageTracker {
+	if options.Prices == nil {
+		options.Prices = DefaultPriceTable
+	}
+	if options.Windows <= 0 {
+		options.Windows = defaultUsageWindows
+	}
+	return &UsageTracker{
+		options: options,
+		now:     time.Now,
+		models:  map[string]*UsageTotals{},
+		tags:    map[string]*UsageTotals{},
+	}
+}
+
+type usageTagsKey struct{}
+
+// WithUsageTag returns a context that attributes the usage of the requests made with it to the tag, in
+// addition to the tags of the parent context
+func WithUsageTag(ctx context.Context, tag string) context.Context {
+	parent := usageTags(ctx)
+	tags := make([]string, len(parent), len(parent)+1)
+	copy(tags, parent)
+	return context.WithValue(ctx, usageTagsKey{}, append(tags, tag))
+}
+
+func usageTags(ctx context.Context) []string {
+	tags, _ := ctx.Value(usageTagsKey{}).([]string)
+	return tags
+}
+
+// Record records a request to the model that used the given tokens, attributed to the tags. It returns
+// the estimated cost of the request.
+func (t *UsageTracker) Record(model string, promptTokens, completionTokens int, tags ...string) float64 {
+	usage := UsageTotals{
+		Requests:         1,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		Cost:             t.options.Prices.Cost(model, promptTokens, completionTokens),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total.add(usage)
+	addUsage(t.models, model, usage)
+	for _, tag := range tags {
+		addUsage(t.tags, tag, usage)
+	}
+	if t.options.Window > 0 {
+		t.window(t.now()).add(usage)
+	}
+	return usage.Cost
+}
+
+func addUsage(totals map[string]*UsageTotals, key string, usage UsageTotals) {
+	total, ok := totals[key]
+	if !ok {
+		total = &UsageTotals{}
+		totals[key] = total
+	}
+	total.add(usage)
+}
+
+// window returns the totals of the window of now, dropping the windows that are too old
+func (t *UsageTracker) window(now time.Time) *UsageTotals {
+	start := now.Truncate(t.options.Window)
+	if n := len(t.windows); n > 0 && t.windows[n-1].Start.Equal(start) {
+		return &t.windows[n-1].UsageTotals
+	}
+	t.windows = append(t.windows, &UsageWindow{Start: start})
+	if len(t.windows) > t.options.Windows {
+		t.windows = t.windows[len(t.windows)-t.options.Windows:]
+	}
+	return &t.windows[len(t.windows)-1].UsageTotals
+}
+
+// Snapshot returns a copy of the usage recorded so far
+func (t *UsageTracker) Snapshot() UsageSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := UsageSnapshot{
+		Total:  t.total,
+		Models: make(map[string]UsageTotals, len(t.models)),
+		Tags:   make(map[string]UsageTotals, len(t.tags)),
+	}
+	for model, usage := range t.models {
+		snapshot.Models[model] = *usage
+	}
+	for tag, usage := range t.tags {
+		snapshot.Tags[tag] = *usage
+	}
+	for _, window := range t.windows {
+		snapshot.Windows = append(snapshot.Windows, *window)
+	}
+	sort.Slice(snapshot.Windows, func(i, j int) bool {
+		return snapshot.Windows[i].Start.Before(snapshot.Windows[j].Start)
+	})
+	return snapshot
+}
+
+// MarshalJSON encodes a snapshot of the usage
+func (t *UsageTracker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Snapshot())
+}
+
+// middleware records the usage of every successful call
+func (t *UsageTracker) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(call *Call) (*http.Response, error) {
+		resp, err := next(call)
+		if err != nil {
+			return nil, err
+		}
+		tags := usageTags(call.Request.Context())
+		InspectResponse(resp, call.Stream, func(info ResponseInfo) {
+			// the usage is recorded for the model that generated the response, like a dated snapshot
+			model := info.Model
+			if model == "" {
+				model = call.Model()
+			}
+			t.Record(model, info.PromptTokens, info.CompletionTokens, tags...)
+		})
+		return resp, nil
+	}
+}
@@ -0,0 +1,85 @@
+package gpt3
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestUsageTracker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)}
+	tracker := NewUsageTracker(UsageTrackerOptions{Window: time.Hour, Windows: 2})
+	tracker.now = clock.Now
+
+	assert.InDelta(t, 0.045, tracker.Record("gpt-4-0314", 1000, 250, "team-a"), 1e-9)
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Record("gpt-3.5-turbo-0301", 500, 500, "team-a", "batch")
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Record("text-embedding-ada-002", 1000, 0)
+	tracker.Record("whisper-1", 0, 0)
+
+	snapshot := tracker.Snapshot()
+	assert.Equal(t, 4, snapshot.Total.Requests)
+	assert.Equal(t, 3250, snapshot.Total.TotalTokens)
+	assert.InDelta(t, 0.045+0.002+0.0004, snapshot.Total.Cost, 1e-9)
+
+	assert.Len(t, snapshot.Models, 4)
+	assert.Equal(t, 1250, snapshot.Models["gpt-4-0314"].TotalTokens)
+	assert.Equal(t, 2, snapshot.Tags["team-a"].Requests)
+	assert.Equal(t, 1, snapshot.Tags["batch"].Requests)
+
+	// only the two most recent windows are kept
+	assert.Len(t, snapshot.Windows, 2)
+	assert.Equal(t, time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC), snapshot.Windows[0].Start)
+	assert.Equal(t, 1, snapshot.Windows[0].Requests)
+	assert.Equal(t, 2, snapshot.Windows[1].Requests)
+
+	data, err := json.Marshal(tracker)
+	assert.NoError(t, err)
+	var decoded UsageSnapshot
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, snapshot, decoded)
+}
+
+func TestWithUsageTracker(t *testing.T) {
+	ctx := context.Background()
+	rt, httpClient := fakeHttpClient()
+	tracker := NewUsageTracker(UsageTrackerOptions{})
+	client := NewClient("test-key", WithHTTPClient(httpClient), WithUsageTracker(tracker))
+
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return statusResponse(200, nil, `{
+			"model": "text-davinci-003",
+			"choices": [{"text": "hi", "index": 0, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 100, "completion_tokens": 50, "total_tokens": 150}
+		}`), nil
+	}
+	rsp, err := client.Completion(WithUsageTag(WithUsageTag(ctx, "user-1"), "summaries"), CompletionRequest{Prompt: PromptString("hello")})
+	assert.NoError(t, err)
+	assert.Equal(t, CompletionResponseUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150}, rsp.Usage)
+
+	_, err = client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.NoError(t, err)
+
+	rt.RoundTripStub = func(*http.Request) (*http.Response, error) {
+		return statusResponse(500, nil, `{"error":{"type":"server_error"}}`), nil
+	}
+	_, err = client.Completion(ctx, CompletionRequest{Prompt: PromptString("hello")})
+	assert.Error(t, err)
+
+	snapshot := tracker.Snapshot()
+	assert.Equal(t, UsageTotals{
+		Requests:         2,
+		PromptTokens:     200,
+		CompletionTokens: 100,
+		TotalTokens:      300,
+		Cost:             0.006,
+	}, snapshot.Models["text-davinci-003"])
+	assert.Equal(t, 1, snapshot.Tags["user-1"].Requests)
+	assert.Equal(t, 1, snapshot.Tags["summaries"].Requests)
+	assert.Empty(t, snapshot.Windows)
+}